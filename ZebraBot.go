@@ -1,6 +1,9 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"math/rand"
+)
 
 //import "flag"
 
@@ -21,7 +24,7 @@ type ZebraBot struct {
 
 }
 
-func (b* ZebraBot) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants) {
+func (b* ZebraBot) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) {
 	b.myNumber = myNumber
 	b.trackList = trackList
 	b.constants = constants
@@ -38,9 +41,12 @@ func (b* ZebraBot) initialize(myNumber int, trackList []Track, adjList [][]int,
 	//b.populateAdjacencyList()
 }
 
-func (b* ZebraBot) informStatus(trackStatus []int, faceUpCards []int) {
-	b.faceUpCards=faceUpCards
-	b.trackStatus=trackStatus
+func (b* ZebraBot) informStatus(view PlayerView) {
+	b.faceUpCards=view.FaceUpTrainCards()
+	//	the engine's count of my cards and trains is the right one: tunnels and ferries can cost other cards than I took off
+	b.myTrainCards=view.TrainCards()
+	b.myTrains=view.NumTrains(b.myNumber)
+	b.trackStatus=view.TrackStatus()
 
 }
 
@@ -69,11 +75,20 @@ func (b* ZebraBot) informDestinationTicketPickup(int) {
 	//	do nothing
 }
 
+func (b* ZebraBot) informFinalRound(int) {
+	//	do nothing
+}
+
+func (b* ZebraBot) informGameResumed(numTrains []int) {
+	b.myTrains = numTrains[b.myNumber]
+}
+
 
 func (b* ZebraBot) whichTrackCanILay() (int, GameColor) {
 	//fmt.Println("Inside whichTrackCAniLay")
 	//fmt.Println("I have destinations:", b.myDestinationTickets[0],b.myDestinationTickets[1])
 	tracksZebraWants:=make([]int,0)
+	view := trackView(b.myNumber, b.trackList, b.trackStatus, b.myTrainCards, b.myTrains)
 	for x:=0;x<b.constants.NumGameColors;x++{
 		b.cardsAnimalsWant[b.myNumber][x]=0
 	}
@@ -93,21 +108,10 @@ func (b* ZebraBot) whichTrackCanILay() (int, GameColor) {
 		if wantedTrack.length>b.myTrains{
 			continue
 		}
-		if wantedTrack.c==Other {
-			for _, allcolor:=range listOfGameColors{
-				if allcolor!=Rainbow {
-					if wantedTrack.length <= b.myTrainCards[allcolor]+b.myTrainCards[Rainbow]{
-						return wantedTrackID, allcolor
-					}
-
-				}
-			}
-		} else {
-			if b.myTrainCards[wantedTrack.c] + b.myTrainCards[Rainbow] >= wantedTrack.length {
-				return wantedTrackID, wantedTrack.c
-			}else{
-				b.cardsAnimalsWant[b.myNumber][wantedTrack.c]+=wantedTrack.length
-			}
+		if payments := trackPayments(view, wantedTrackID); len(payments) > 0 {
+			return wantedTrackID, payments[0].Color
+		} else if wantedTrack.c!=Other {
+			b.cardsAnimalsWant[b.myNumber][wantedTrack.c]+=wantedTrack.length
 		}
 
 	}
@@ -115,18 +119,10 @@ func (b* ZebraBot) whichTrackCanILay() (int, GameColor) {
 			if b.trackStatus[i]!=-1 || b.myTrains<track.length {
 				continue
 			}
-			if track.c==Other && track.length>4 {
-				for _, allcolor:=range listOfGameColors{
-					if allcolor!=Rainbow {
-						if track.length <= b.myTrainCards[allcolor]+b.myTrainCards[Rainbow]{
-							return i, allcolor
-						}
-					}
-				}
-			} else if track.length>4{
-				if b.myTrainCards[track.c] + b.myTrainCards[Rainbow] >= track.length {
-					return i, track.c
-				}else{
+			if track.length>4 {
+				if payments := trackPayments(view, i); len(payments) > 0 {
+					return i, payments[0].Color
+				} else if track.c!=Other {
 					b.cardsAnimalsWant[b.myNumber][track.c]+=track.length
 				}
 			}
@@ -140,14 +136,14 @@ func (b* ZebraBot) whichTrackCanILay() (int, GameColor) {
 func (b* ZebraBot) bfs(dt1 Destination, dt2 Destination)[]int{
 
 	//fmt.Println("inside bfs")
-	var visited= make([]bool, NUMDESTINATIONS)
-	var pred= make([]Destination, NUMDESTINATIONS)
-	var dist=make([]int, NUMDESTINATIONS)
-	var trackids=make([]int,NUMDESTINATIONS)
+	var visited= make([]bool, b.constants.NumDestinations)
+	var pred= make([]Destination, b.constants.NumDestinations)
+	var dist=make([]int, b.constants.NumDestinations)
+	var trackids=make([]int,b.constants.NumDestinations)
 
 	var returnTracks=make([]int,0)
 
-	for i:=0;i<NUMDESTINATIONS;i++{
+	for i:=0;i<b.constants.NumDestinations;i++{
 		visited[i]=false
 		pred[i]=-1
 		dist[i]=100000
@@ -204,11 +200,13 @@ func (b* ZebraBot) bfs(dt1 Destination, dt2 Destination)[]int{
 	return returnTracks
 }
 
-func (b* ZebraBot) askTrackLay() (int, GameColor){
+func (b* ZebraBot) askTrackLay() (int, Payment){
 	trackIndex, trackColor:=b.whichTrackCanILay()
 	if trackIndex==-1{
 		panic("whichTrackCanILay in error, panic, panic, panic")
 	}
+	//	pay with as many cards of the color as I can, and rainbows for the rest
+	payment := payWith(b.myTrainCards, trackColor, b.trackList[trackIndex].length, b.trackList[trackIndex].locomotives)
 	if b.trackList[trackIndex].length>b.myTrainCards[trackColor]{
 		b.myTrainCards[Rainbow]-= b.trackList[trackIndex].length-b.myTrainCards[trackColor]
 		b.myTrainCards[trackColor]=0
@@ -216,10 +214,20 @@ func (b* ZebraBot) askTrackLay() (int, GameColor){
 		b.myTrainCards[trackColor]-=b.trackList[trackIndex].length
 	}
 	b.myTrains-=b.trackList[trackIndex].length
-	return trackIndex, trackColor
+	return trackIndex, payment
 
 
-} //ask this player which track he wants to lay, and with what color
+} //ask this player which track he wants to lay, and how he pays for it
+
+func (b* ZebraBot) askStation() (Destination, Payment) {
+	//	zebra never builds stations
+	return -1, Payment{Color: Other}
+}
+
+func (b* ZebraBot) askTunnelPayment(int, []GameColor, Payment) bool {
+	//	always pay for the tunnel: my cards are counted again before my next turn
+	return true
+}
 
 
 
@@ -237,8 +245,8 @@ func (b* ZebraBot) askMove() int{
 
 
 
-func (b* ZebraBot) askPickup(howManyLeft int, faceUpCards[]int) GameColor {
-	b.faceUpCards = faceUpCards
+func (b* ZebraBot) askPickup(howManyLeft int, view PlayerView) GameColor {
+	b.faceUpCards = view.FaceUpTrainCards()
 	mostreq:=0
 	mostreqind:=-1
 	for i,color:= range b.faceUpCards{
@@ -272,7 +280,7 @@ func (b* ZebraBot) giveDestinationTicket(d DestinationTicket) {
 	fmt.Println("inside destination ticket offering to Player", b.myNumber)
 	b.myDestinationTickets=append(b.myDestinationTickets, d)
 	b.DestinationTicketStatus=append(b.DestinationTicketStatus,-1)
-	fmt.Println("Player", b.myNumber, " got Destination ticket from",destinationNames[d.d1],"TO", destinationNames[d.d2], "worth points ", d.points)
+	fmt.Println("Player", b.myNumber, " got Destination ticket from",b.constants.DestinationNames[d.d1],"TO", b.constants.DestinationNames[d.d2], "worth points ", d.points)
 	//fmt.Println("Destination Cards giving")
 	//	basic player doesn't care about destination tickets, so do nothing
 } //tell this player has a destination card
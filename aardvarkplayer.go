@@ -47,6 +47,8 @@ type AardvarkPlayer struct {
 	trackScores []float64
 
 	lastChosentrack int
+
+	rng *rand.Rand //my own random source, handed to me by the engine
 }
 
 func (a *AardvarkPlayer) populateAdjacencyList() {
@@ -63,7 +65,7 @@ func (a *AardvarkPlayer) populateAdjacencyList() {
 }
 
 
-func (a* AardvarkPlayer) initialize(myNumber int, trackList []Track,adjList [][]int, constants GameConstants) {
+func (a* AardvarkPlayer) initialize(myNumber int, trackList []Track,adjList [][]int, constants GameConstants, rng *rand.Rand) {
 	a.myNumber = myNumber
 	a.trackList = trackList
 	a.constants = constants
@@ -75,6 +77,7 @@ func (a* AardvarkPlayer) initialize(myNumber int, trackList []Track,adjList [][]
 	a.lastChosentrack = -1
 
 	a.adjacencyList = adjList
+	a.rng = rng
 }
 
 func (a *AardvarkPlayer) getOtherDestination(d Destination, t Track) Destination {
@@ -279,9 +282,12 @@ func (a* AardvarkPlayer) difficultyOfGettingTrack(trid int) float64{
 	return ans
 }
 
-func (a* AardvarkPlayer) informStatus(trackStatus []int, faceUpCards []int) {
-	a.faceUpCards=faceUpCards
-	a.trackStatus=trackStatus
+func (a* AardvarkPlayer) informStatus(view PlayerView) {
+	a.faceUpCards=view.FaceUpTrainCards()
+	//	the engine's count of my cards and trains is the right one: tunnels and ferries can cost other cards than I took off
+	a.myTrainCards=view.TrainCards()
+	a.myTrains=view.NumTrains(a.myNumber)
+	a.trackStatus=view.TrackStatus()
 
 	a.trackScores = make([]float64,a.constants.NumTracks)
 	destinationTicketScores := make([]float64,a.constants.NumTracks)
@@ -353,11 +359,21 @@ func (a* AardvarkPlayer) informDestinationTicketPickup(int) {
 	//	do nothing
 }
 
-func (a* AardvarkPlayer) askTrackLay() (int, GameColor){
+func (a* AardvarkPlayer) informFinalRound(int) {
+	//	do nothing
+}
+
+func (a* AardvarkPlayer) informGameResumed(numTrains []int) {
+	a.myTrains = numTrains[a.myNumber]
+}
+
+func (a* AardvarkPlayer) askTrackLay() (int, Payment){
 	canLay,c := a.canILayThisTrack(a.lastChosentrack)
 	if !canLay {
 		panic("I THOUGHT I COULD LAY THIS TRACK BUT I CANT")
 	}
+	//	pay with as many cards of the color as I can, and rainbows for the rest
+	payment := payWith(a.myTrainCards, c, a.trackList[a.lastChosentrack].length, a.trackList[a.lastChosentrack].locomotives)
 
 	if a.trackList[a.lastChosentrack].length>a.myTrainCards[c]{
 		a.myTrainCards[Rainbow]-= a.trackList[a.lastChosentrack].length-a.myTrainCards[c]
@@ -367,34 +383,42 @@ func (a* AardvarkPlayer) askTrackLay() (int, GameColor){
 	}
 	a.myTrains-=a.trackList[a.lastChosentrack].length
 
-	return a.lastChosentrack, c
-} //ask this player which track he wants to lay, and with what color
+	return a.lastChosentrack, payment
+} //ask this player which track he wants to lay, and how he pays for it
+
+func (a* AardvarkPlayer) askStation() (Destination, Payment) {
+	//	aardvark never builds stations
+	return -1, Payment{Color: Other}
+}
+
+func (a* AardvarkPlayer) askTunnelPayment(int, []GameColor, Payment) bool {
+	//	always pay for the tunnel: my cards are counted again before my next turn
+	return true
+}
 
 func (a* AardvarkPlayer) canILayThisTrack(trid int) (bool, GameColor) {
+	payments := trackPayments(trackView(a.myNumber, a.trackList, a.trackStatus, a.myTrainCards, a.myTrains), trid)
+	if len(payments) > 0 {
+		return true, payments[0].Color
+	}
+
+	//	I can't lay it: return the color I'm closest to paying with
 	bestColor := a.trackList[trid].c
 	bestColorVal := -1
 	if a.trackList[trid].c==Other {
 		for _, allcolor:=range listOfGameColors{
-			if allcolor!=Rainbow {
-				if a.trackList[trid].length <= a.myTrainCards[allcolor]+a.myTrainCards[Rainbow]{
-					return true, allcolor
-				} else if a.myTrainCards[allcolor]+a.myTrainCards[Rainbow] > bestColorVal{
-					bestColorVal = a.myTrainCards[allcolor]+a.myTrainCards[Rainbow]
-					bestColor = allcolor
-				}
+			if allcolor!=Rainbow && a.myTrainCards[allcolor]+a.myTrainCards[Rainbow] > bestColorVal{
+				bestColorVal = a.myTrainCards[allcolor]+a.myTrainCards[Rainbow]
+				bestColor = allcolor
 			}
 		}
-	} else {
-		if a.myTrainCards[a.trackList[trid].c] + a.myTrainCards[Rainbow] >= a.trackList[trid].length {
-			return true, a.trackList[trid].c
-		}
 	}
 	return false, bestColor
 }
 
 func (a* AardvarkPlayer) askMove() int{
 	//	in an askMove, we should have already filled trackScores, so here we just randomly sample from the distribution
-	randomNumber := rand.Float64()
+	randomNumber := a.rng.Float64()
 	selector := 0
 	cumulativeProbability := float64(0)
 	for ;selector<a.constants.NumTracks;selector++ {
@@ -428,13 +452,13 @@ func (a* AardvarkPlayer) askMove() int{
 	}
 } //Ask the player what move he wants to do: 0 is pick up cards, 1 is place Tracks, 2 is pick destination ticket
 
-func (a* AardvarkPlayer) askPickup(howManyLeft int, faceUpCards []int) GameColor {
+func (a* AardvarkPlayer) askPickup(howManyLeft int, view PlayerView) GameColor {
 
 	if a.lastChosentrack == a.constants.NumTracks {
 		return Other
 	}
 
-	a.faceUpCards = faceUpCards
+	a.faceUpCards = view.FaceUpTrainCards()
 	canLayTrack, c := a.canILayThisTrack(a.lastChosentrack)
 	if canLayTrack && howManyLeft == 2 {
 		panic("I thought I couldn't lay this track but I can")
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/geckods/ticket-to-ride/ttr"
+)
+
+//apiPlayer seats a bot written against the exported API in package ttr at the engine, translating between the engine's types and the API's
+//the engine's own bots keep implementing Player directly
+type apiPlayer struct {
+	player ttr.Player
+	view   PlayerView //the view of the last informStatus, which the payments of a bot that only chooses colors are worked out from
+}
+
+//newAPIPlayer wraps a bot, if it was written against this version of the API
+func newAPIPlayer(p ttr.Player) (*apiPlayer, error) {
+	if p.APIVersion() != ttr.APIVersion {
+		return nil, fmt.Errorf("the player %T was written against version %d of the player API, but the engine speaks version %d", p, p.APIVersion(), ttr.APIVersion)
+	}
+	return &apiPlayer{player: p}, nil
+}
+
+//newRegisteredPlayer makes a bot registered with ttr.Register, ready to be seated
+func newRegisteredPlayer(name string) (Player, error) {
+	p, err := ttr.NewPlayer(name)
+	if err != nil {
+		return nil, err
+	}
+	return newAPIPlayer(p)
+}
+
+func apiTracks(tracks []Track) []ttr.Track {
+	apiTracks := make([]ttr.Track, len(tracks))
+	for i, t := range tracks {
+		if t.kind == NormalRoute {
+			apiTracks[i] = ttr.NewTrack(t.idx, ttr.Destination(t.d1), ttr.Destination(t.d2), ttr.GameColor(t.c), t.length)
+		} else {
+			apiTracks[i] = ttr.NewTunnelOrFerry(t.idx, ttr.Destination(t.d1), ttr.Destination(t.d2), ttr.GameColor(t.c), t.length, ttr.RouteType(t.kind), t.locomotives)
+		}
+	}
+	return apiTracks
+}
+
+func apiTicket(ticket DestinationTicket) ttr.DestinationTicket {
+	return ttr.NewDestinationTicket(ttr.Destination(ticket.d1), ttr.Destination(ticket.d2), ticket.points)
+}
+
+func apiTickets(tickets []DestinationTicket) []ttr.DestinationTicket {
+	apiTickets := make([]ttr.DestinationTicket, len(tickets))
+	for i, ticket := range tickets {
+		apiTickets[i] = apiTicket(ticket)
+	}
+	return apiTickets
+}
+
+func apiRules(constants GameConstants) ttr.Rules {
+	return ttr.Rules{
+		NumDestinations:                         constants.NumDestinations,
+		NumTracks:                               constants.NumTracks,
+		NumColorCards:                           constants.NumColorCards,
+		NumRainbowCards:                         constants.NumRainbowCards,
+		NumStartingTrains:                       constants.NumStartingTrains,
+		NumTrainsForFinalRound:                  constants.NumTrainsForFinalRound,
+		NumFaceUpTrainCards:                     constants.NumFaceUpTrainCards,
+		NumFaceUpRainbowsForReshuffle:           constants.NumFaceUpRainbowsForReshuffle,
+		NumGameColors:                           constants.NumGameColors,
+		NumInitialTrainCardsDealt:               constants.NumInitialTrainCardsDealt,
+		NumInitialDestinationTicketsOffered:     constants.NumInitialDestinationTicketsOffered,
+		NumInitialDestinationTicketsPicked:      constants.NumInitialDestinationTicketsPicked,
+		NumDestinationTicketsOffered:            constants.NumDestinationTicketsOffered,
+		NumDestinationTicketsPicked:             constants.NumDestinationTicketsPicked,
+		NumPlayers:                              constants.NumPlayers,
+		LongestPathScore:                        constants.LongestPathScore,
+		DoubleRouteMinPlayers:                   constants.DoubleRouteMinPlayers,
+		NumStations:                             constants.NumStations,
+		StationScore:                            constants.StationScore,
+		NumTunnelCards:                          constants.NumTunnelCards,
+		NumInitialLongDestinationTicketsOffered: constants.NumInitialLongDestinationTicketsOffered,
+		GlobetrotterScore:                       constants.GlobetrotterScore,
+		RouteLengthScores:                       append([]int(nil), constants.routeLengthScores...),
+	}
+}
+
+func apiView(view PlayerView) ttr.PlayerView {
+	return ttr.NewPlayerView(ttr.ViewState{
+		Player:                    view.player,
+		Tracks:                    apiTracks(view.tracks),
+		TrackStatus:               view.trackStatus,
+		FaceUpTrainCards:          view.faceUpTrainCards,
+		DeckSize:                  view.deckSize,
+		DiscardPileSize:           view.discardPileSize,
+		DestinationTicketPileSize: view.destinationTicketPileSize,
+		NumTrainCards:             view.numTrainCards,
+		NumTrains:                 view.numTrains,
+		NumDestinationTickets:     view.numDestinationTickets,
+		StationsLeft:              view.stationsLeft,
+		Stations:                  view.stations,
+		TrainCards:                view.trainCards,
+		DestinationTickets:        apiTickets(view.destinationTickets),
+	})
+}
+
+func (a *apiPlayer) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) {
+	adjacency := make([][]int, len(adjList))
+	for i := range adjList {
+		adjacency[i] = append([]int(nil), adjList[i]...)
+	}
+	a.player.Initialize(myNumber, apiTracks(trackList), adjacency, apiRules(constants), rng)
+}
+
+func (a *apiPlayer) informCardPickup(player int, color GameColor) {
+	a.player.InformCardPickup(player, ttr.GameColor(color))
+}
+
+func (a *apiPlayer) informTrackLay(player int, track int) {
+	a.player.InformTrackLay(player, track)
+}
+
+func (a *apiPlayer) informDestinationTicketPickup(player int) {
+	a.player.InformDestinationTicketPickup(player)
+}
+
+func (a *apiPlayer) informFinalRound(player int) {
+	a.player.InformFinalRound(player)
+}
+
+func (a *apiPlayer) informGameResumed(numTrains []int) {
+	a.player.InformGameResumed(numTrains)
+}
+
+func (a *apiPlayer) informStatus(view PlayerView) {
+	a.view = view
+	a.player.InformStatus(apiView(view))
+}
+
+func (a *apiPlayer) askMove() int {
+	return int(a.player.AskMove())
+}
+
+func (a *apiPlayer) askPickup(howManyLeft int, view PlayerView) GameColor {
+	return GameColor(a.player.AskPickup(howManyLeft, apiView(view)))
+}
+
+//askTrackLay asks the bot which track to claim, and how to pay for it if it chooses that itself
+func (a *apiPlayer) askTrackLay() (int, Payment) {
+	if payer, ok := a.player.(ttr.SplitPayer); ok {
+		track, payment := payer.AskTrackLayPayment()
+		return track, enginePayment(payment)
+	}
+	track, color := a.player.AskTrackLay()
+	return track, defaultTrackPayment(a.view, track, GameColor(color))
+}
+
+//askStation asks the bot where to build a station, if it builds stations at all
+func (a *apiPlayer) askStation() (Destination, Payment) {
+	if builder, ok := a.player.(ttr.SplitStationBuilder); ok {
+		city, payment := builder.AskStationPayment()
+		return Destination(city), enginePayment(payment)
+	}
+	builder, ok := a.player.(ttr.StationBuilder)
+	if !ok {
+		return -1, Payment{Color: Other}
+	}
+	city, color := builder.AskStation()
+	return Destination(city), defaultStationPayment(a.view, GameColor(color))
+}
+
+//useContext passes the context of the next call on to the bot, if it can give up a call that ran out of time
+func (a *apiPlayer) useContext(ctx context.Context) {
+	if user, ok := a.player.(ttr.ContextUser); ok {
+		user.UseContext(ctx)
+	}
+}
+
+func enginePayment(payment ttr.Payment) Payment {
+	return Payment{Color: GameColor(payment.Color), NumColored: payment.NumColored, NumRainbows: payment.NumRainbows}
+}
+
+//askTunnelPayment asks the bot whether to pay for a tunnel, if it decides that itself
+func (a *apiPlayer) askTunnelPayment(track int, drawn []GameColor, extra Payment) bool {
+	payer, ok := a.player.(ttr.TunnelPayer)
+	if !ok {
+		return true
+	}
+	apiDrawn := make([]ttr.GameColor, len(drawn))
+	for i, c := range drawn {
+		apiDrawn[i] = ttr.GameColor(c)
+	}
+	return payer.AskTunnelPayment(track, apiDrawn, ttr.Payment{Color: ttr.GameColor(extra.Color), NumColored: extra.NumColored, NumRainbows: extra.NumRainbows})
+}
+
+func (a *apiPlayer) giveTrainCard(color GameColor) {
+	a.player.GiveTrainCard(ttr.GameColor(color))
+}
+
+func (a *apiPlayer) giveDestinationTicket(ticket DestinationTicket) {
+	a.player.GiveDestinationTicket(apiTicket(ticket))
+}
+
+func (a *apiPlayer) offerDestinationTickets(tickets []DestinationTicket, minKept int) []int {
+	return a.player.OfferDestinationTickets(apiTickets(tickets), minKept)
+}
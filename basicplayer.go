@@ -1,5 +1,7 @@
 package main
 
+import "math/rand"
+
 type BasicPlayer struct {
 	trackList []Track //my copy of the board
 	trackStatus []int //my copy of the status of each track
@@ -12,7 +14,7 @@ type BasicPlayer struct {
 	constants GameConstants
 }
 
-func (b* BasicPlayer) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants) {
+func (b* BasicPlayer) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) {
 	b.myNumber = myNumber
 	b.trackList = trackList
 	b.constants = constants
@@ -22,9 +24,12 @@ func (b* BasicPlayer) initialize(myNumber int, trackList []Track, adjList [][]in
 	b.myDestinationTickets=make([]DestinationTicket,0)
 }
 
-func (b* BasicPlayer) informStatus(trackStatus []int, faceUpCards []int) {
- 	b.faceUpCards=faceUpCards
- 	b.trackStatus=trackStatus
+func (b* BasicPlayer) informStatus(view PlayerView) {
+ 	b.faceUpCards=view.FaceUpTrainCards()
+	//	the engine's count of my cards and trains is the right one: tunnels and ferries can cost other cards than I took off
+	b.myTrainCards=view.TrainCards()
+	b.myTrains=view.NumTrains(b.myNumber)
+ 	b.trackStatus=view.TrackStatus()
 
 }
 func (b* BasicPlayer) informCardPickup(int, GameColor) {
@@ -39,32 +44,29 @@ func (b* BasicPlayer) informDestinationTicketPickup(int) {
 	//	do nothing
 }
 
+func (b* BasicPlayer) informFinalRound(int) {
+	//	do nothing
+}
+
+func (b* BasicPlayer) informGameResumed(numTrains []int) {
+	b.myTrains = numTrains[b.myNumber]
+}
+
 func (b* BasicPlayer) whichTrackCanILay() (int, GameColor) {
-	for i,track := range b.trackList { //rainbow
-		if b.trackStatus[i]!=-1 || b.myTrains<track.length {
-			continue
-		}
-		if track.c==Other {
-			for _, allcolor:=range listOfGameColors{
-				if allcolor!=Rainbow {
-					if track.length <= b.myTrainCards[allcolor]+b.myTrainCards[Rainbow]{
-						return i, allcolor
-					}
-				}
-			}
-		} else {
-			if b.myTrainCards[track.c] + b.myTrainCards[Rainbow] >= track.length {
-				return i, track.c
-			}
-		}
+	//	the first track I can claim, with the first color I can pay for it with
+	tracks := claimableTracks(trackView(b.myNumber, b.trackList, b.trackStatus, b.myTrainCards, b.myTrains))
+	if len(tracks) == 0 {
+		return -1, Other
 	}
-	return -1, Other
+	return tracks[0].Track, tracks[0].Payments[0].Color
 }
-func (b* BasicPlayer) askTrackLay() (int, GameColor){
+func (b* BasicPlayer) askTrackLay() (int, Payment){
 	trackIndex, trackColor:=b.whichTrackCanILay()
 	if trackIndex==-1{
 		panic("whichTrackCanILay in error, panic, panic, panic")
 	}
+	//	pay with as many cards of the color as I can, and rainbows for the rest
+	payment := payWith(b.myTrainCards, trackColor, b.trackList[trackIndex].length, b.trackList[trackIndex].locomotives)
 	if b.trackList[trackIndex].length>b.myTrainCards[trackColor]{
 		b.myTrainCards[Rainbow]-= b.trackList[trackIndex].length-b.myTrainCards[trackColor]
 		b.myTrainCards[trackColor]=0
@@ -72,10 +74,20 @@ func (b* BasicPlayer) askTrackLay() (int, GameColor){
 		b.myTrainCards[trackColor]-=b.trackList[trackIndex].length
 	}
 	b.myTrains-=b.trackList[trackIndex].length
-	return trackIndex, trackColor
+	return trackIndex, payment
+
 
+} //ask this player which track he wants to lay, and how he pays for it
 
-} //ask this player which track he wants to lay, and with what color
+func (b* BasicPlayer) askStation() (Destination, Payment) {
+	//	basic player never builds stations
+	return -1, Payment{Color: Other}
+}
+
+func (b* BasicPlayer) askTunnelPayment(int, []GameColor, Payment) bool {
+	//	always pay for the tunnel: my cards are counted again before my next turn
+	return true
+}
 
 func (b* BasicPlayer) askMove() int{
 	whichTrack,_ := b.whichTrackCanILay()
@@ -87,8 +99,8 @@ func (b* BasicPlayer) askMove() int{
 
 } //Ask the player what move he wants to do: 0 is pick up cards, 1 is place Tracks, 2 is pick destination ticket
 
-func (b* BasicPlayer) askPickup(howManyLeft int, faceUpCards[]int) GameColor {
-	b.faceUpCards = faceUpCards
+func (b* BasicPlayer) askPickup(howManyLeft int, view PlayerView) GameColor {
+	b.faceUpCards = view.FaceUpTrainCards()
 	return Other
 }   //ask this player, given the gamestate, which card he wants to pick up
 
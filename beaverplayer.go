@@ -82,6 +82,8 @@ type BeaverPlayer struct {
 	constantForRepeat float64
 
 	sampleNumber int
+
+	rng *rand.Rand //my own random source, handed to me by the engine
 }
 
 func (b *BeaverPlayer) populateAdjacencyList() {
@@ -117,7 +119,7 @@ func (b *BeaverPlayer) setScoringParameters(inputs [] float64) {
 	b.sampleNumber = int(math.Floor(scaleFloat(inputs[8], sampleNumberMin, sampleNumberMax)))
 }
 
-func (b * BeaverPlayer) initialize(myNumber int, trackList []Track,adjList [][]int, constants GameConstants) {
+func (b * BeaverPlayer) initialize(myNumber int, trackList []Track,adjList [][]int, constants GameConstants, rng *rand.Rand) {
 	b.myNumber = myNumber
 	b.trackList = trackList
 	b.constants = constants
@@ -129,6 +131,7 @@ func (b * BeaverPlayer) initialize(myNumber int, trackList []Track,adjList [][]i
 	b.lastChosentrack = -1
 
 	b.adjacencyList = adjList
+	b.rng = rng
 }
 
 func (b *BeaverPlayer) getOtherDestination(d Destination, t Track) Destination {
@@ -413,9 +416,12 @@ func (b * BeaverPlayer) difficultyOfGettingTrack(trid int) float64{
 	return ans
 }
 
-func (b * BeaverPlayer) informStatus(trackStatus []int, faceUpCards []int) {
-	b.faceUpCards=faceUpCards
-	b.trackStatus=trackStatus
+func (b * BeaverPlayer) informStatus(view PlayerView) {
+	b.faceUpCards=view.FaceUpTrainCards()
+	//	the engine's count of my cards and trains is the right one: tunnels and ferries can cost other cards than I took off
+	b.myTrainCards=view.TrainCards()
+	b.myTrains=view.NumTrains(b.myNumber)
+	b.trackStatus=view.TrackStatus()
 
 	b.trackScores = make([]float64, b.constants.NumTracks)
 	destinationTicketScores := make([]float64, b.constants.NumTracks)
@@ -505,11 +511,21 @@ func (b* BeaverPlayer) informDestinationTicketPickup(int) {
 	//	do nothing
 }
 
-func (b * BeaverPlayer) askTrackLay() (int, GameColor){
+func (b* BeaverPlayer) informFinalRound(int) {
+	//	do nothing
+}
+
+func (b* BeaverPlayer) informGameResumed(numTrains []int) {
+	b.myTrains = numTrains[b.myNumber]
+}
+
+func (b * BeaverPlayer) askTrackLay() (int, Payment){
 	canLay,c := b.canILayThisTrack(b.lastChosentrack)
 	if !canLay {
 		panic("I THOUGHT I COULD LAY THIS TRACK BUT I CANT")
 	}
+	//	pay with as many cards of the color as I can, and rainbows for the rest
+	payment := payWith(b.myTrainCards, c, b.trackList[b.lastChosentrack].length, b.trackList[b.lastChosentrack].locomotives)
 
 	if b.trackList[b.lastChosentrack].length> b.myTrainCards[c]{
 		b.myTrainCards[Rainbow]-= b.trackList[b.lastChosentrack].length- b.myTrainCards[c]
@@ -519,27 +535,35 @@ func (b * BeaverPlayer) askTrackLay() (int, GameColor){
 	}
 	b.myTrains-= b.trackList[b.lastChosentrack].length
 
-	return b.lastChosentrack, c
-} //ask this player which track he wants to lay, and with what color
+	return b.lastChosentrack, payment
+} //ask this player which track he wants to lay, and how he pays for it
+
+func (b * BeaverPlayer) askStation() (Destination, Payment) {
+	//	beaver never builds stations
+	return -1, Payment{Color: Other}
+}
+
+func (b * BeaverPlayer) askTunnelPayment(int, []GameColor, Payment) bool {
+	//	always pay for the tunnel: my cards are counted again before my next turn
+	return true
+}
 
 func (b * BeaverPlayer) canILayThisTrack(trid int) (bool, GameColor) {
+	payments := trackPayments(trackView(b.myNumber, b.trackList, b.trackStatus, b.myTrainCards, b.myTrains), trid)
+	if len(payments) > 0 {
+		return true, payments[0].Color
+	}
+
+	//	I can't lay it: return the color I'm closest to paying with
 	bestColor := b.trackList[trid].c
 	bestColorVal := -1
 	if b.trackList[trid].c==Other {
 		for _, allcolor:=range listOfGameColors{
-			if allcolor!=Rainbow {
-				if b.trackList[trid].length <= b.myTrainCards[allcolor]+b.myTrainCards[Rainbow]{
-					return true, allcolor
-				} else if b.myTrainCards[allcolor]+b.myTrainCards[Rainbow] > bestColorVal{
-					bestColorVal = b.myTrainCards[allcolor]+ b.myTrainCards[Rainbow]
-					bestColor = allcolor
-				}
+			if allcolor!=Rainbow && b.myTrainCards[allcolor]+b.myTrainCards[Rainbow] > bestColorVal{
+				bestColorVal = b.myTrainCards[allcolor]+b.myTrainCards[Rainbow]
+				bestColor = allcolor
 			}
 		}
-	} else {
-		if b.myTrainCards[b.trackList[trid].c] + b.myTrainCards[Rainbow] >= b.trackList[trid].length {
-			return true, b.trackList[trid].c
-		}
 	}
 	return false, bestColor
 }
@@ -562,7 +586,7 @@ func (b * BeaverPlayer) askMove() int{
 
 
 	for i:=0;i<b.sampleNumber;i++ {
-		randomNumber := rand.Float64()
+		randomNumber := b.rng.Float64()
 		selector := 0
 		cumulativeProbability := float64(0)
 		for ;selector< b.constants.NumTracks;selector++ {
@@ -604,7 +628,9 @@ func (b * BeaverPlayer) askMove() int{
 		}
 	}
 
-	b.chosenMove = moveselectionSlice[rand.Intn(len(moveselectionSlice))]
+	//map iteration order is random, so sort to keep the choice reproducible
+	sort.Ints(moveselectionSlice)
+	b.chosenMove = moveselectionSlice[b.rng.Intn(len(moveselectionSlice))]
 	if b.chosenMove < b.constants.NumTracks {
 		b.lastChosentrack = b.chosenMove
 		return 1
@@ -614,7 +640,8 @@ func (b * BeaverPlayer) askMove() int{
 
 } //Ask the player what move he wants to do: 0 is pick up cards, 1 is place Tracks, 2 is pick destination ticket
 
-func (b * BeaverPlayer) askPickup(howManyLeft int, faceUpCards []int) GameColor {
+func (b * BeaverPlayer) askPickup(howManyLeft int, view PlayerView) GameColor {
+	b.faceUpCards = view.FaceUpTrainCards()
 	c := GameColor(b.chosenMove - b.constants.NumTracks)
 	if b.faceUpCards[c] > 0 {
 		return c
@@ -89,37 +89,28 @@ func (g* GA_Beaver) fillInPopulationScores(){
 
 	for numIter:=0;numIter<g.numGamesInTournament;numIter++ {
 
-		channelArray := make([][]chan bool, g.populationSize)
-		for i:=0;i<g.populationSize;i++ {
-			channelArray[i] = make([]chan bool, g.populationSize)
-			for j:=0;j<g.populationSize;j++ {
-				channelArray[i][j] = make(chan bool)
-			}
-		}
-
+		//every ordered pair of distinct individuals plays one game
+		pairs := make([][2]int, 0, g.populationSize*g.populationSize)
 		for i:=0;i<g.populationSize;i++ {
 			for j:=0;j<g.populationSize;j++ {
 				if i==j {
 					continue
 				}
-				go g.twoWayTourney(g.population[i],g.population[j],channelArray[i][j])
+				pairs = append(pairs, [2]int{i, j})
 			}
 		}
 
-		for i:=0;i<g.populationSize;i++ {
-			for j:=0;j<g.populationSize;j++ {
-				if i==j {
-					continue
-				}
-				res := <- channelArray[i][j]
-				//fmt.Println("RES", res)
-				if res {
-					g.popscores[j].score+=1.0
-				} else {
-					g.popscores[i].score += 1.0
-				}
-				totSum += 1.0
-			}
+		gameResults := runGamesInParallel(len(pairs), *numWorkers, boardConstants(), func(gameNumber int) (*Engine, []Player) {
+			return g.twoWayTourney(g.population[pairs[gameNumber][0]], g.population[pairs[gameNumber][1]])
+		})
+
+		for gameNumber, result := range gameResults {
+			i, j := pairs[gameNumber][0], pairs[gameNumber][1]
+			//fmt.Println("RES", result.Winners)
+			aPoints, bPoints := g.twoWayTourneyPoints(result.Winners)
+			g.popscores[i].score += aPoints
+			g.popscores[j].score += bPoints
+			totSum += aPoints + bPoints
 		}
 	}
 
@@ -160,76 +151,70 @@ func (g* GA_Beaver) fillInPopulationScores(){
 //	return toReturn
 //}
 
-func (g* GA_Beaver) twoWayTourney(a,b individual, ch chan bool){
-	constants := GameConstants{
-		NumColorCards:                       NUMCOLORCARDS,
-		NumRainbowCards:                     NUMRAINBOWCARDS,
-		NumStartingTrains:                   NUMSTARTINGTRAINS,
-		NumFaceUpTrainCards:                 NUMFACEUPTRAINCARDS,
-		NumGameColors:                       NUMGAMECOLORS,
-		NumInitialTrainCardsDealt:           NUMINITIALTRAINCARDSDEALT,
-		NumInitialDestinationTicketsOffered: NUMINITIALDESTINATIONTICKETSOFFERED,
-		NumInitialDestinationTicketsPicked:  NUMINITIALDESTINATIONTICKETSPICKED,
-		NumDestinationTicketsOffered:        NUMDESTINATIONTICKETSOFFERED,
-		NumDestinationTicketsPicked:         NUMDESTINATIONTICKETSPICKED,
-		LongestPathScore:                    LONGESTPATHSCORE,
-		NumPlayers:                          0,
-		NumTracks:                           0,
-		NumDestinations:                     NUMDESTINATIONS,
-		routeLengthScores:                   routeLengthScores,
-	}
+//twoWayTourney sets up a game of -numPlayers seats between copies of a and b, seated a, b, a, b and so on
+//with -bot, the bot takes the last two seats, so with four seats a and b play once each against it
+//with an odd number of seats a has one more than b, which twoWayTourneyPoints makes up for
+func (g* GA_Beaver) twoWayTourney(a,b individual) (*Engine, []Player) {
 	e := Engine{}
 	e.OptimizerMode = true
+	e.Board = gameBoard
+	e.Seed = rand.Int63()
 	players := make([]Player, 0)
-	player1 := BeaverPlayer{}
-	player1.setScoringParameters(a)
-	players = append(players, &player1)
-	player2 := BeaverPlayer{}
-	player2.setScoringParameters(b)
-	players = append(players, &player2)
-	player3 := BeaverPlayer{}
-	player3.setScoringParameters(a)
-	players = append(players, &player3)
-	player4 := BeaverPlayer{}
-	player4.setScoringParameters(b)
-	players = append(players, &player4)
-
-	winners := e.runGame(players, constants)
-	//fmt.Println(winners)
-	if winners[0] == 0 || winners[0]==2 {
-		//fmt.Println("Writing To Channel")
-		ch <- false
-	} else {
-		//fmt.Println("Writing To Channel")
-		ch <- true
+	for seat:=0;seat<*numPlayers;seat++ {
+		player := BeaverPlayer{}
+		if seat%2 == 0 {
+			player.setScoringParameters(a)
+		} else {
+			player.setScoringParameters(b)
+		}
+		players = append(players, &player)
+	}
+	seatExternalBots(players)
+
+	return &e, players
+}
+
+//twoWayTourneySeats counts the seats of a and of b in a game set up by twoWayTourney
+func twoWayTourneySeats() (aSeats, bSeats int) {
+	for seat := 0; seat < *numPlayers; seat++ {
+		if *botCommand != "" && seat >= *numPlayers-2 {
+			continue
+		}
+		if seat%2 == 0 {
+			aSeats++
+		} else {
+			bSeats++
+		}
 	}
+	return aSeats, bSeats
+}
 
+//twoWayTourneyPoints scores a game set up by twoWayTourney for a and for b
+//a win is worth more to whichever of them has fewer seats, so that they score the same on average if they play as well as each other; with as many seats each, a win is worth 1
+func (g* GA_Beaver) twoWayTourneyPoints(winners []int) (float64, float64) {
+	//fmt.Println(winners)
+	if len(winners) == 0 || (*botCommand != "" && winners[0] >= *numPlayers-2) {
+		//	nobody won, or the bot did, so call it a coin toss
+		if rand.Intn(2) == 0 {
+			return 0, 1
+		}
+		return 1, 0
+	}
+	aSeats, bSeats := twoWayTourneySeats()
+	if winners[0]%2 == 1 {
+		return 0, float64(aSeats+bSeats) / float64(2*bSeats)
+	}
+	return float64(aSeats+bSeats) / float64(2*aSeats), 0
 }
 
 func (g* GA_Beaver) tournament(inds [4]individual) int{
 
 	scores := make([]int, 4)
-	for i:=0;i<g.numGamesInTournament;i++ {
-
-		constants := GameConstants{
-			NumColorCards:                       NUMCOLORCARDS,
-			NumRainbowCards:                     NUMRAINBOWCARDS,
-			NumStartingTrains:                   NUMSTARTINGTRAINS,
-			NumFaceUpTrainCards:                 NUMFACEUPTRAINCARDS,
-			NumGameColors:                       NUMGAMECOLORS,
-			NumInitialTrainCardsDealt:           NUMINITIALTRAINCARDSDEALT,
-			NumInitialDestinationTicketsOffered: NUMINITIALDESTINATIONTICKETSOFFERED,
-			NumInitialDestinationTicketsPicked:  NUMINITIALDESTINATIONTICKETSPICKED,
-			NumDestinationTicketsOffered:        NUMDESTINATIONTICKETSOFFERED,
-			NumDestinationTicketsPicked:         NUMDESTINATIONTICKETSPICKED,
-			LongestPathScore:                    LONGESTPATHSCORE,
-			NumPlayers:                          0,
-			NumTracks:                           0,
-			NumDestinations:                     NUMDESTINATIONS,
-			routeLengthScores:                   routeLengthScores,
-		}
+	gameResults := runGamesInParallel(g.numGamesInTournament, *numWorkers, boardConstants(), func(gameNumber int) (*Engine, []Player) {
 		e := Engine{}
 		e.OptimizerMode = true
+		e.Board = gameBoard
+		e.Seed = rand.Int63()
 		players := make([]Player, 0)
 		player1 := BeaverPlayer{}
 		player1.setScoringParameters(inds[0])
@@ -243,9 +228,11 @@ func (g* GA_Beaver) tournament(inds [4]individual) int{
 		player4 := BeaverPlayer{}
 		player4.setScoringParameters(inds[3])
 		players = append(players, &player4)
+		return &e, players
+	})
 
-		winners := e.runGame(players, constants)
-		for _,winner := range winners {
+	for _,result := range gameResults {
+		for _,winner := range result.Winners {
 			scores[winner]++
 		}
 	}
@@ -288,7 +275,22 @@ func (g* GA_Beaver) rouletteWheelSelection() int {
 	return -1
 }
 
+//gaResultsFile is where GA training writes its populations: every variant is trained separately, so each one has its own file
+func gaResultsFile(variant Variant) string {
+	if variant == StandardGame {
+		return "garesults.txt"
+	}
+	return "garesults-" + variant.String() + ".txt"
+}
+
 func optimizeBeaverParametersWithGeneticAlgorithm() {
+	if _, err := boardConstants().forPlayers(*numPlayers); err != nil {
+		log.Fatal(err)
+	}
+	if *botCommand != "" && *numPlayers < 4 {
+		log.Fatal("with -bot, training games need at least 4 players: the bot takes the last two seats, and both individuals need one")
+	}
+
 	g := GA_Beaver{}
 	g.fillInParameters("gaparams.txt")
 
@@ -343,7 +345,7 @@ func optimizeBeaverParametersWithGeneticAlgorithm() {
 		g.population = newPopulation
 
 		if iterationNumber%fileWriteFrequency == 0 {
-			file, err := os.OpenFile("garesults.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			file, err := os.OpenFile(gaResultsFile(gameVariant), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 			//defer file.Close()
 			if err != nil {
 				log.Fatal(err)
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+const DEFAULTMAPNAME = "usa" //the built in map in gameconstants.go
+
+//RouteType says which rules a route is claimed under
+type RouteType int
+
+const (
+	NormalRoute RouteType = iota //paid with cards of its color, or of any one color if it is grey
+	TunnelRoute                  //like a normal route, but NumTunnelCards cards are turned over as it is claimed, and each one that matches the cards paid costs one more
+	FerryRoute                   //like a normal route, but some of the cards paying for it must be rainbows
+)
+
+var routeTypeNames = []string{"normal", "tunnel", "ferry"}
+
+func (t RouteType) String() string {
+	if t < 0 || int(t) >= len(routeTypeNames) {
+		return "unknown route type " + strconv.Itoa(int(t))
+	}
+	return routeTypeNames[t]
+}
+
+//parseRouteType reads the type of a route in a map file, where no type at all is a normal route
+func parseRouteType(name string) (RouteType, error) {
+	if name == "" {
+		return NormalRoute, nil
+	}
+	for i, typeName := range routeTypeNames {
+		if typeName == name {
+			return RouteType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown route type %q, expected one of %v", name, routeTypeNames)
+}
+
+//Board is a map to play on: its cities, its tracks, its destination tickets and how routes are scored
+//the engine copies whatever it changes, so one board can be shared by many games at once
+type Board struct {
+	Name   string
+	source string //DEFAULTMAPNAME for the built in board, or the map file it was loaded from: records and snapshots name their board by it
+	Rules  Ruleset
+
+	DestinationNames  []string          //indexed by Destination
+	Positions         map[string]string //where to draw some of the cities, as "x,y" by city name; the others are placed by graphviz
+	Tracks            []Track
+	DoubleRoutes      [][]int //groups of parallel tracks that form a double route
+	Tickets           []DestinationTicket
+	LongTickets       []DestinationTicket             //the long destination tickets, which the Europe rules deal one of to every player at the start
+	TicketDecks       map[Variant][]DestinationTicket //the decks that replace Tickets in the expanded and hub cities variants
+	RouteLengthScores []int                           //the points for claiming a route, by its length
+
+	MinPlayers, MaxPlayers int
+}
+
+//usaBoard is the built in board, made of the lists in gameconstants.go
+var usaBoard = &Board{
+	Name:              "USA",
+	source:            DEFAULTMAPNAME,
+	DestinationNames:  destinationNames,
+	Positions:         mapPositions,
+	Tracks:            listOfTracks,
+	DoubleRoutes:      listOfDoubleRoutes,
+	Tickets:           listOfDestinationTickets,
+	TicketDecks:       map[Variant][]DestinationTicket{ExpandedDeckGame: listOfExpandedDestinationTickets, HubCitiesGame: listOfHubCitiesDestinationTickets},
+	RouteLengthScores: routeLengthScores,
+	MinPlayers:        MINPLAYERS,
+	MaxPlayers:        MAXPLAYERS,
+}
+
+//gameConstants returns the constants of the standard game on this board, under its rules
+func (b *Board) gameConstants() GameConstants {
+	c := b.Rules.gameConstants()
+	c.routeLengthScores = b.RouteLengthScores
+	c.MinPlayers, c.MaxPlayers = b.MinPlayers, b.MaxPlayers
+	return c.onBoard(b)
+}
+
+//onBoard returns the constants with what they say about the board filled in from b: its cities, tracks and tickets
+func (c GameConstants) onBoard(b *Board) GameConstants {
+	c.NumDestinations = len(b.DestinationNames)
+	c.NumTracks = len(b.Tracks)
+	c.NumDestinationTickets = len(b.tickets(c.Variant))
+	c.NumLongDestinationTickets = len(b.LongTickets)
+	c.DestinationNames = append([]string(nil), b.DestinationNames...)
+	return c
+}
+
+//copied returns the constants with slices of their own, so that a player given them can't change the engine's
+func (c GameConstants) copied() GameConstants {
+	c.DestinationNames = append([]string(nil), c.DestinationNames...)
+	c.PlayerCountRules = append([]PlayerCountRule(nil), c.PlayerCountRules...)
+	c.routeLengthScores = append([]int(nil), c.routeLengthScores...)
+	return c
+}
+
+//tickets returns the ticket deck of a variant, which is empty if the board has none for it
+func (b *Board) tickets(v Variant) []DestinationTicket {
+	switch v {
+	case StandardGame:
+		return b.Tickets
+	case AllTicketsGame:
+		if len(b.TicketDecks) == 0 {
+			return nil
+		}
+		return allTicketsGameTickets(b.Tickets, b.TicketDecks[ExpandedDeckGame], b.TicketDecks[HubCitiesGame])
+	}
+	return b.TicketDecks[v]
+}
+
+//loadBoard returns the built in board for DEFAULTMAPNAME, and otherwise reads the map file of that name
+func loadBoard(name string) (*Board, error) {
+	if name == "" || name == DEFAULTMAPNAME {
+		//	records and snapshots from before there were map files name no board
+		return usaBoard, nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	board, err := readBoard(file)
+	if err != nil {
+		return nil, fmt.Errorf("map file %s: %v", name, err)
+	}
+	board.source = name
+	return board, nil
+}
+
+//MapFile is how a board is written in a map file, in JSON
+type MapFile struct {
+	Name        string
+	Rules       string `json:",omitempty"` //the edition of the rules the map is played with (default usa)
+	Cities      []MapCity
+	Routes      []MapRoute
+	Tickets     []MapTicket
+	LongTickets []MapTicket            `json:",omitempty"` //the long destination tickets of the Europe rules
+	TicketDecks map[string][]MapTicket `json:",omitempty"` //the decks of the expanded and hubcities variants
+	RouteScores []int                  //the points for claiming a route, by its length: RouteScores[0] is never used
+
+	MinPlayers int `json:",omitempty"` //the fewest players the map is for (default 2)
+	MaxPlayers int `json:",omitempty"` //the most players the map is for (default 5)
+}
+
+type MapCity struct {
+	Name     string
+	Position []float64 `json:",omitempty"` //x and y, to draw the city at
+}
+
+type MapRoute struct {
+	From, To    string
+	Color       string //one of the card colors, or grey for a route any one color can pay for
+	Length      int
+	DoubleRoute int    `json:",omitempty"` //routes with the same number are the parallel halves of a double route; 0 is a single route
+	Type        string `json:",omitempty"` //the rules the route is claimed under (default normal)
+	Locomotives int    `json:",omitempty"` //for ferries: how many of the cards paying for the route must be rainbows
+}
+
+type MapTicket struct {
+	From, To string
+	Points   int
+}
+
+//readBoard reads a map file, and checks that the engine can play on it: every city is known, every color is a route color, every length can be scored and every ferry takes locomotives
+func readBoard(r io.Reader) (*Board, error) {
+	var m MapFile
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m.board()
+}
+
+func (m MapFile) board() (*Board, error) {
+	b := &Board{Name: m.Name, Positions: make(map[string]string), RouteLengthScores: m.RouteScores, MinPlayers: m.MinPlayers, MaxPlayers: m.MaxPlayers}
+	rules, err := parseRuleset(m.Rules)
+	if err != nil {
+		return nil, err
+	}
+	b.Rules = rules
+	if b.MinPlayers == 0 {
+		b.MinPlayers = MINPLAYERS
+	}
+	if b.MaxPlayers == 0 {
+		b.MaxPlayers = MAXPLAYERS
+	}
+	if len(m.RouteScores) < 2 {
+		return nil, fmt.Errorf("the route scores must give the points for routes of length 1 and up")
+	}
+
+	cities := make(map[string]Destination)
+	for i, city := range m.Cities {
+		if city.Name == "" {
+			return nil, fmt.Errorf("city %d has no name", i)
+		}
+		if _, ok := cities[city.Name]; ok {
+			return nil, fmt.Errorf("there are two cities named %s", city.Name)
+		}
+		cities[city.Name] = Destination(i)
+		b.DestinationNames = append(b.DestinationNames, city.Name)
+
+		switch len(city.Position) {
+		case 0:
+		case 2:
+			b.Positions[city.Name] = strconv.FormatFloat(city.Position[0], 'g', -1, 64) + "," + strconv.FormatFloat(city.Position[1], 'g', -1, 64)
+		default:
+			return nil, fmt.Errorf("the position of %s must be x and y", city.Name)
+		}
+	}
+	city := func(name string) (Destination, error) {
+		d, ok := cities[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown city %q", name)
+		}
+		return d, nil
+	}
+
+	doubleRoutes := make(map[int]int) //the index in b.DoubleRoutes of every double route number
+	for i, route := range m.Routes {
+		from, err := city(route.From)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %v", i, err)
+		}
+		to, err := city(route.To)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %v", i, err)
+		}
+		color, err := parseRouteColor(route.Color)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %v", i, err)
+		}
+		if route.Length < 1 || route.Length >= len(m.RouteScores) {
+			return nil, fmt.Errorf("route %d is %d long, but the route scores only go from 1 to %d", i, route.Length, len(m.RouteScores)-1)
+		}
+		routeType, err := parseRouteType(route.Type)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %v", i, err)
+		}
+		if routeType == FerryRoute && (route.Locomotives < 1 || route.Locomotives > route.Length) {
+			return nil, fmt.Errorf("route %d is a ferry, so between 1 and its length of %d of the cards paying for it must be locomotives, not %d", i, route.Length, route.Locomotives)
+		}
+		if routeType != FerryRoute && route.Locomotives != 0 {
+			return nil, fmt.Errorf("route %d takes %d locomotives, but only ferries take locomotives", i, route.Locomotives)
+		}
+
+		b.Tracks = append(b.Tracks, Track{idx: i, d1: from, d2: to, c: color, length: route.Length, kind: routeType, locomotives: route.Locomotives})
+		if route.DoubleRoute != 0 {
+			group, ok := doubleRoutes[route.DoubleRoute]
+			if !ok {
+				group = len(b.DoubleRoutes)
+				doubleRoutes[route.DoubleRoute] = group
+				b.DoubleRoutes = append(b.DoubleRoutes, nil)
+			}
+			b.DoubleRoutes[group] = append(b.DoubleRoutes[group], i)
+		}
+	}
+
+	if b.Tickets, err = m.tickets(m.Tickets, "ticket", city); err != nil {
+		return nil, err
+	}
+	if b.LongTickets, err = m.tickets(m.LongTickets, "long ticket", city); err != nil {
+		return nil, err
+	}
+	for name, deck := range m.TicketDecks {
+		variant, err := parseVariant(name)
+		if err != nil {
+			return nil, err
+		}
+		if variant != ExpandedDeckGame && variant != HubCitiesGame {
+			return nil, fmt.Errorf("the %s variant has no deck of its own", variant)
+		}
+		if b.TicketDecks == nil {
+			b.TicketDecks = make(map[Variant][]DestinationTicket)
+		}
+		if b.TicketDecks[variant], err = m.tickets(deck, name+" ticket", city); err != nil {
+			return nil, err
+		}
+	}
+	if len(b.LongTickets) > 0 && b.Rules.gameConstants().NumInitialLongDestinationTicketsOffered == 0 {
+		return nil, fmt.Errorf("the %s rules never deal long tickets, so the map can't have any", b.Rules)
+	}
+	return b, nil
+}
+
+func (m MapFile) tickets(tickets []MapTicket, what string, city func(string) (Destination, error)) ([]DestinationTicket, error) {
+	destinationTickets := make([]DestinationTicket, 0)
+	for i, ticket := range tickets {
+		from, err := city(ticket.From)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %v", what, i, err)
+		}
+		to, err := city(ticket.To)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %v", what, i, err)
+		}
+		destinationTickets = append(destinationTickets, DestinationTicket{d1: from, d2: to, points: ticket.Points})
+	}
+	return destinationTickets, nil
+}
+
+//parseRouteColor reads the color of a route: any card color but rainbow, or grey
+func parseRouteColor(name string) (GameColor, error) {
+	for c, colorName := range stringColors {
+		if colorName == name && GameColor(c) != Rainbow {
+			return GameColor(c), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown route color %q", name)
+}
@@ -0,0 +1,6 @@
+package main
+
+//bots written in their own modules register themselves with ttr.Register when their package is imported
+//to seat one by name, import its package here for its side effects, like
+//	_ "github.com/someteam/mybot"
+import ()
@@ -0,0 +1,93 @@
+#!/usr/bin/env python3
+"""An example bot for the protocol in PROTOCOL.md.
+
+It claims a random track whenever it can, and otherwise draws train cards,
+using the legal moves the engine sends with every informStatus.
+Run it against the Go bots with:
+
+    ./ticket-to-ride -statisticsMode -numGames 100 -bot "python3 bots/example_bot.py"
+"""
+
+import json
+import random
+import sys
+
+PROTOCOL_VERSION = 1
+
+DRAW_TRAIN_CARDS, CLAIM_TRACK, DRAW_DESTINATION_TICKETS, BUILD_STATION = 0, 1, 2, 3
+OTHER = 9  # the top card of the deck
+
+
+class ExampleBot:
+    def __init__(self):
+        self.rng = random.Random()
+        self.legal_moves = None
+        self.chosen_track = None
+
+    def initialize(self, message):
+        self.rng.seed(message["Seed"])
+        self.player = message["Player"]
+        self.tracks = message["Tracks"]
+        return {"ProtocolVersion": PROTOCOL_VERSION, "Name": "example"}
+
+    def inform_status(self, message):
+        self.legal_moves = message["LegalMoves"]
+
+    def ask_move(self, message):
+        moves = self.legal_moves["Moves"]
+        if CLAIM_TRACK in moves:
+            self.chosen_track = self.rng.choice(self.legal_moves["Tracks"])
+            return {"Move": CLAIM_TRACK}
+        if DRAW_TRAIN_CARDS in moves:
+            return {"Move": DRAW_TRAIN_CARDS}
+        return {"Move": self.rng.choice(moves)}
+
+    def ask_pickup(self, message):
+        pickups = message["LegalPickups"]
+        if OTHER in pickups:
+            return {"Color": OTHER}
+        return {"Color": self.rng.choice(pickups)}
+
+    def ask_track_lay(self, message):
+        payment = self.chosen_track["Payments"][0]
+        return {"Track": self.chosen_track["Track"], "Color": payment["Color"], "Rainbows": payment["NumRainbows"]}
+
+    def ask_station(self, message):
+        # only asked when a station is one of the legal moves, so there is a city to build in
+        return {"City": self.legal_moves["Stations"][0], "Color": self.legal_moves["StationPayments"][0]["Color"]}
+
+    def ask_tunnel_payment(self, message):
+        return {"Pay": True}
+
+    def offer_destination_tickets(self, message):
+        # keep the cheapest tickets: they are the least likely to cost points
+        tickets = message["Tickets"]
+        cheapest = sorted(range(len(tickets)), key=lambda i: tickets[i]["Points"])
+        return {"Kept": cheapest[: message["MinKept"]]}
+
+
+def main():
+    bot = ExampleBot()
+    questions = {
+        "initialize": bot.initialize,
+        "askMove": bot.ask_move,
+        "askPickup": bot.ask_pickup,
+        "askTrackLay": bot.ask_track_lay,
+        "askStation": bot.ask_station,
+        "askTunnelPayment": bot.ask_tunnel_payment,
+        "offerDestinationTickets": bot.offer_destination_tickets,
+    }
+    for line in sys.stdin:
+        message = json.loads(line)
+        kind = message["Type"]
+        if kind == "gameOver":
+            break
+        if kind == "informStatus":
+            bot.inform_status(message)
+        elif kind in questions:
+            print(json.dumps(questions[kind](message)), flush=True)
+        # the other messages tell the bot about the game; this bot doesn't need them
+
+
+if __name__ == "__main__":
+    main()
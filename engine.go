@@ -2,10 +2,8 @@ package main
 
 import (
 	"fmt"
-	"go.uber.org/zap"
 	"math/rand"
 	"os"
-	"os/exec"
 	"strconv"
 )
 
@@ -28,13 +26,37 @@ type Engine struct {
 	pileOfTrainCards         []GameColor         //the facedown stack of train cards
 	discardPileOfTrainCards  []GameColor
 	pileOfDestinationTickets []DestinationTicket //the facedown stack of destination tickets
+	pileOfLongDestinationTickets []DestinationTicket //the long tickets of the Europe rules, which are only dealt at the start
+
+	stations     []int //who built a station in each city, or -1 if nobody has
+	stationsLeft []int //how many stations the i'th player can still build
 
 	gameConstants GameConstants
 
+	Board         *Board //the map to play on: the built in USA board if nil
 	adjacencyList [][]int
+	doubleRouteSiblings [][]int //for each track, the other tracks of its double route (empty for single routes)
 
 	OptimizerMode bool
 	falseMoveCount int
+	numTurns       int           //how many turns have been played, including those of disqualified players
+	endReason      GameEndReason //why the game ended, once it has
+
+	observers []Observer //told about everything that happens in the game
+
+	Seed int64      //the seed for all of the game's randomness: the same seed and players replay the same game
+	rng       *rand.Rand      //the engine's own random source, seeded with Seed
+	rngSource *countingSource //the source behind rng, which knows how far along it is for snapshots
+
+	finalRoundTriggeredBy int //the player whose trains ran low, or -1 if the final round hasn't started
+	turnsLeftInFinalRound int //how many turns remain once the final round has started
+
+	KeepTies bool //every player tied on points wins, instead of the official tie breakers deciding
+
+	IllegalMovePolicy IllegalMovePolicy //what to do when a player breaks a rule
+	ruleViolations    []RuleViolation   //every illegal move made in this game, and the policy applied to it
+	disqualified      []bool            //players who have been disqualified for illegal moves
+	aborted           bool              //whether the game was stopped because of an illegal move
 }
 
 func (e *Engine) initializePileOfTrainCards(toExclude []int) {
@@ -54,7 +76,7 @@ func (e *Engine) initializePileOfTrainCards(toExclude []int) {
 	}
 
 	//shuffle the deck
-	rand.Shuffle(len(e.pileOfTrainCards), func(i, j int) {
+	e.rng.Shuffle(len(e.pileOfTrainCards), func(i, j int) {
 		e.pileOfTrainCards[i], e.pileOfTrainCards[j] = e.pileOfTrainCards[j], e.pileOfTrainCards[i]
 	})
 }
@@ -80,7 +102,7 @@ func (e *Engine) drawTopTrainCard() GameColor {
 		} else {
 			e.pileOfTrainCards = e.discardPileOfTrainCards
 			e.discardPileOfTrainCards = nil
-			rand.Shuffle(len(e.pileOfTrainCards), func(i, j int) {
+			e.rng.Shuffle(len(e.pileOfTrainCards), func(i, j int) {
 				e.pileOfTrainCards[i],e.pileOfTrainCards[j]=e.pileOfTrainCards[j],e.pileOfTrainCards[i]
 			})
 		}
@@ -93,23 +115,69 @@ func (e *Engine) drawTopTrainCard() GameColor {
 	return element
 }
 
-func (e *Engine) logGiveCardToPlayer(p int, c GameColor, toHideColorWhenInforming bool) {
-	if *toLog {
-		zap.L().Info("Engine: Giving a card of Color "+stringColors[c]+" to player "+strconv.Itoa(p),
-			zap.String("EVENT", "GIVING_TRAIN_CARD"),
-			zap.String("COLOR", stringColors[c]),
-			zap.Int("PLAYER", p),
-		)
+func (e *Engine) numTrainCardsLeftToDraw() int {
+	return len(e.pileOfTrainCards) + len(e.discardPileOfTrainCards)
+}
+
+func (e *Engine) numFaceUpTrainCards() int {
+	total := 0
+	for _, count := range e.faceUpTrainCards {
+		total += count
 	}
+	return total
+}
 
-	if *toUseVisualizer {
-		server.BroadcastToNamespace("/", "ENGINE_UPDATE", "Engine: Giving a card of Color "+stringColors[c]+" to player "+strconv.Itoa(p))
+//deal face up cards until there are NumFaceUpTrainCards of them, or until we run out of cards to deal
+func (e *Engine) fillFaceUpTrainCards() {
+	for e.numFaceUpTrainCards() < e.gameConstants.NumFaceUpTrainCards && e.numTrainCardsLeftToDraw() > 0 {
+		e.faceUpTrainCards[e.drawTopTrainCard()]++
+	}
+}
+
+func (e *Engine) discardFaceUpTrainCards() {
+	for c, count := range e.faceUpTrainCards {
+		for i := 0; i < count; i++ {
+			e.discardPileOfTrainCards = append(e.discardPileOfTrainCards, GameColor(c))
+		}
+		e.faceUpTrainCards[c] = 0
+	}
+}
+
+//checks whether the cards that are face up or still to be dealt can make up a market with fewer than NumFaceUpRainbowsForReshuffle rainbows at all
+//if not, reshuffling forever would never help, so we keep whatever is on the table
+func (e *Engine) canDealValidFaceUpTrainCards() bool {
+	totalCards := e.numFaceUpTrainCards() + e.numTrainCardsLeftToDraw()
+	nonRainbowCards := totalCards - e.faceUpTrainCards[Rainbow]
+
+	for _, c := range e.pileOfTrainCards {
+		if c == Rainbow {
+			nonRainbowCards--
+		}
+	}
+	for _, c := range e.discardPileOfTrainCards {
+		if c == Rainbow {
+			nonRainbowCards--
+		}
+	}
+
+	marketSize := min(e.gameConstants.NumFaceUpTrainCards, totalCards)
+	return nonRainbowCards > marketSize-e.gameConstants.NumFaceUpRainbowsForReshuffle
+}
+
+//top up the face up cards, and apply the three locomotives rule: while too many of the face up cards are rainbows, discard all of them and deal a fresh set
+func (e *Engine) refillFaceUpTrainCards() {
+	e.fillFaceUpTrainCards()
+
+	for e.faceUpTrainCards[Rainbow] >= e.gameConstants.NumFaceUpRainbowsForReshuffle && e.canDealValidFaceUpTrainCards() {
+		e.emit(FaceUpCardsReshuffled{NumRainbows: e.faceUpTrainCards[Rainbow]})
+		e.discardFaceUpTrainCards()
+		e.fillFaceUpTrainCards()
 	}
 }
 
 func (e *Engine) giveCardToPlayer(p int, c GameColor, toHideColorWhenInforming bool) {
 
-	e.logGiveCardToPlayer(p,c,toHideColorWhenInforming)
+	e.emit(CardDrawn{Player: p, Color: c, Hidden: toHideColorWhenInforming})
 	//update the engine's copy
 	e.trainCardHands[p][c]++
 	//give the player his card
@@ -125,24 +193,7 @@ func (e *Engine) giveCardToPlayer(p int, c GameColor, toHideColorWhenInforming b
 	}
 }
 
-func (e *Engine) logGiveDestinationTicketToPlayer(p int, ticket DestinationTicket){
-	if *toLog {
-		zap.L().Info("Engine: Giving a destination ticket from "+e.destinationNames[ticket.d1]+" to "+e.destinationNames[ticket.d2]+" of score "+strconv.Itoa(ticket.points)+" to player "+strconv.Itoa(p),
-			zap.String("EVENT", "GIVING_DESTINATION_TICKET"),
-			zap.String("DEST_1", e.destinationNames[ticket.d1]),
-			zap.String("DEST_2", e.destinationNames[ticket.d2]),
-			zap.Int("SCORE", ticket.points),
-			zap.Int("PLAYER", p),
-		)
-	}
-
-	if *toUseVisualizer {
-		server.BroadcastToNamespace("/", "ENGINE_UPDATE", "Engine: Giving a destination ticket from "+e.destinationNames[ticket.d1]+" to "+e.destinationNames[ticket.d2]+" of score "+strconv.Itoa(ticket.points)+" to player "+strconv.Itoa(p))
-	}
-}
-
 func (e *Engine) giveDestinationTicketToPlayer(p int, ticket DestinationTicket) {
-	e.logGiveDestinationTicketToPlayer(p, ticket)
 	//update the engine's copy
 	e.destinationTicketHands[p] = append(e.destinationTicketHands[p], ticket)
 	//give the player his card
@@ -154,28 +205,51 @@ func (e *Engine) giveDestinationTicketToPlayer(p int, ticket DestinationTicket)
 }
 
 func (e *Engine) initializeDestinationTicketPile() {
-	//assign
-	e.pileOfDestinationTickets = listOfDestinationTickets
+	//assign a copy, so that shuffling doesn't touch the shared list
+	tickets := e.board().tickets(e.gameConstants.Variant)
+	e.pileOfDestinationTickets = make([]DestinationTicket, len(tickets))
+	copy(e.pileOfDestinationTickets, tickets)
 
 	//	shuffle
-	rand.Shuffle(len(e.pileOfDestinationTickets), func(i, j int) {
+	e.rng.Shuffle(len(e.pileOfDestinationTickets), func(i, j int) {
 		e.pileOfDestinationTickets[i], e.pileOfDestinationTickets[j] = e.pileOfDestinationTickets[j], e.pileOfDestinationTickets[i]
 	})
 
 }
 
+func (e *Engine) initializeLongDestinationTicketPile() {
+	e.pileOfLongDestinationTickets = make([]DestinationTicket, len(e.board().LongTickets))
+	copy(e.pileOfLongDestinationTickets, e.board().LongTickets)
+
+	e.rng.Shuffle(len(e.pileOfLongDestinationTickets), func(i, j int) {
+		e.pileOfLongDestinationTickets[i], e.pileOfLongDestinationTickets[j] = e.pileOfLongDestinationTickets[j], e.pileOfLongDestinationTickets[i]
+	})
+}
+
 func (e *Engine) drawTopDestinationTicket() (DestinationTicket, bool) {
-	if len(e.pileOfDestinationTickets) == 0 {
+	return drawTopTicket(&e.pileOfDestinationTickets)
+}
+
+func drawTopTicket(pile *[]DestinationTicket) (DestinationTicket, bool) {
+	if len(*pile) == 0 {
 		return DestinationTicket{}, false
 	}
 
-	index := len(e.pileOfDestinationTickets) - 1                      // Get the index of the top most element.
-	element := (e.pileOfDestinationTickets)[index]                    // Index into the slice and obtain the element.
-	e.pileOfDestinationTickets = (e.pileOfDestinationTickets)[:index] // Remove it from the stack by slicing it off.
+	index := len(*pile) - 1    // Get the index of the top most element.
+	element := (*pile)[index]  // Index into the slice and obtain the element.
+	*pile = (*pile)[:index]    // Remove it from the stack by slicing it off.
 
 	return element, true
 }
 
+//board is the map the engine plays on
+func (e *Engine) board() *Board {
+	if e.Board == nil {
+		return usaBoard
+	}
+	return e.Board
+}
+
 func (e *Engine) populateAdjacencyList() {
 	e.adjacencyList = make([][]int, e.gameConstants.NumDestinations)
 	for i := 0; i < e.gameConstants.NumDestinations; i++ {
@@ -189,23 +263,125 @@ func (e *Engine) populateAdjacencyList() {
 
 }
 
+func (e *Engine) populateDoubleRouteSiblings() {
+	e.doubleRouteSiblings = make([][]int, len(e.trackList))
+
+	for _, doubleRoute := range e.board().DoubleRoutes {
+		for _, track := range doubleRoute {
+			for _, sibling := range doubleRoute {
+				if sibling != track {
+					e.doubleRouteSiblings[track] = append(e.doubleRouteSiblings[track], sibling)
+				}
+			}
+		}
+	}
+}
+
+//a player may never own both halves of a double route, and with fewer than DoubleRouteMinPlayers players only one half can be used at all
+func (e *Engine) doubleRouteAllowsClaim(playerNumber, whichTrack int) bool {
+	for _, sibling := range e.doubleRouteSiblings[whichTrack] {
+		if e.trackStatus[sibling] == -1 {
+			continue
+		}
+		if e.trackStatus[sibling] == playerNumber || e.gameConstants.NumPlayers < e.gameConstants.DoubleRouteMinPlayers {
+			return false
+		}
+	}
+	return true
+}
+
+//the track status as a given player should see it: free tracks that player is not allowed to claim are marked CLOSEDTRACK
+func (e *Engine) trackStatusForPlayer(playerNumber int) []int {
+	status := make([]int, len(e.trackStatus))
+	copy(status, e.trackStatus)
+
+	for i := range status {
+		if status[i] == -1 && !e.doubleRouteAllowsClaim(playerNumber, i) {
+			status[i] = CLOSEDTRACK
+		}
+	}
+	return status
+}
+
+//playerView is what player p may know, built out of copies of the engine's state, so that the view can't change the game
+func (e *Engine) playerView(p int) PlayerView {
+	view := PlayerView{
+		player:                    p,
+		tracks:                    append([]Track(nil), e.trackList...),
+		trackStatus:               e.trackStatusForPlayer(p),
+		faceUpTrainCards:          append([]int(nil), e.faceUpTrainCards...),
+		deckSize:                  len(e.pileOfTrainCards),
+		discardPileSize:           len(e.discardPileOfTrainCards),
+		destinationTicketPileSize: len(e.pileOfDestinationTickets),
+		numTrains:                 append([]int(nil), e.numTrains...),
+		trainCards:                append([]int(nil), e.trainCardHands[p]...),
+		destinationTickets:        append([]DestinationTicket(nil), e.destinationTicketHands[p]...),
+		stations:                  append([]int(nil), e.stations...),
+		stationsLeft:              append([]int(nil), e.stationsLeft...),
+	}
+	for i := range e.playerList {
+		numTrainCards := 0
+		for _, howMany := range e.trainCardHands[i] {
+			numTrainCards += howMany
+		}
+		view.numTrainCards = append(view.numTrainCards, numTrainCards)
+		view.numDestinationTickets = append(view.numDestinationTickets, len(e.destinationTicketHands[i]))
+	}
+	return view
+}
+
+//initializePlayer seats a player, with a random source derived from the game's seed
+//the player gets its own copies of the board and the constants, so nothing it does to them can change the game
+func (e *Engine) initializePlayer(i int, p Player) {
+	adjacencyList := make([][]int, len(e.adjacencyList))
+	for d, tracks := range e.adjacencyList {
+		adjacencyList[d] = append([]int(nil), tracks...)
+	}
+	p.initialize(i, append([]Track(nil), e.trackList...), adjacencyList, e.gameConstants.copied(), rand.New(rand.NewSource(e.rng.Int63())))
+}
+
+//addObserver registers an observer to be told about every event of the games this engine runs
+func (e *Engine) addObserver(o Observer) {
+	e.observers = append(e.observers, o)
+}
+
+func (e *Engine) emit(event GameEvent) {
+	for _, o := range e.observers {
+		o.observe(event)
+	}
+}
+
+//initializeGame sets up a new game for the players on the engine's board; it panics if the constants don't allow a game with that many players, which runners check up front with forPlayers
 func (e *Engine) initializeGame(playerList []Player, constants GameConstants) {
+	board := e.board()
+	constants, err := constants.onBoard(board).forPlayers(len(playerList))
+	if err != nil {
+		panic(err)
+	}
 
-	//TODO: some of these things refer to global variables, ideally we don't want that, everything can be a parameter
+	//everything the engine changes during a game is its own copy, so that many engines can run games at the same time
 	//e.OptimizerMode = false
 	e.falseMoveCount = 0
+	e.numTurns = 0
+	e.rngSource = newCountingSource(e.Seed, 0)
+	e.rng = rand.New(e.rngSource)
+	e.finalRoundTriggeredBy = -1
+	e.turnsLeftInFinalRound = 0
+	e.ruleViolations = nil
+	e.aborted = false
 
 	e.playerList = playerList
 	e.activePlayer = 0
 
-	e.destinationNames=destinationNames
-	e.stringColors=stringColors
+	e.destinationNames = append([]string(nil), board.DestinationNames...)
+	e.stringColors = append([]string(nil), stringColors...)
+
+	e.emit(GameStarted{Seed: e.Seed, NumPlayers: len(e.playerList), DestinationNames: e.destinationNames})
 
 	e.gameConstants = constants
-	e.gameConstants.NumPlayers = len(e.playerList)
+	e.gameConstants.routeLengthScores = append([]int(nil), constants.routeLengthScores...)
 
-	e.trackList = listOfTracks
-	e.gameConstants.NumTracks = len(e.trackList)
+	e.trackList = append([]Track(nil), board.Tracks...)
 	e.trackStatus = make([]int, len(e.trackList))
 
 	for i := range e.trackStatus {
@@ -214,6 +390,9 @@ func (e *Engine) initializeGame(playerList []Player, constants GameConstants) {
 
 	//populate adjacency List
 	e.populateAdjacencyList()
+	e.populateDoubleRouteSiblings()
+
+	e.disqualified = make([]bool, len(e.playerList))
 
 	//set numTrains
 	e.numTrains = make([]int, len(e.playerList))
@@ -221,12 +400,19 @@ func (e *Engine) initializeGame(playerList []Player, constants GameConstants) {
 		e.numTrains[i] = e.gameConstants.NumStartingTrains
 	}
 
+	//nobody has built a station yet
+	e.stations = noStations(e.gameConstants.NumDestinations)
+	e.stationsLeft = make([]int, len(e.playerList))
+	for i := range e.playerList {
+		e.stationsLeft[i] = e.gameConstants.NumStations
+	}
+
 	//ADDING RETURN FOR DEBUGGING PURPOSES
 
 
 	for i, p := range e.playerList {
-		p.initialize(i, e.trackList,e.adjacencyList, e.gameConstants)
 		//	initialize each player
+		e.initializePlayer(i, p)
 	}
 
 	e.faceUpTrainCards = make([]int, e.gameConstants.NumGameColors)
@@ -247,160 +433,286 @@ func (e *Engine) initializeGame(playerList []Player, constants GameConstants) {
 		}
 	}
 
+	//	turn up the initial face up cards
+	e.refillFaceUpTrainCards()
+
 	//	set up the pile of destination tickets
 	e.initializeDestinationTicketPile()
+	e.initializeLongDestinationTicketPile()
 
 	//	give each player destination tickets
 	for i := range e.playerList {
 		//give each player the initial destination tickets
-		e.runDestinationTokenCollectionPhase(i, e.gameConstants.NumInitialDestinationTicketsOffered, e.gameConstants.NumInitialDestinationTicketsPicked)
+		err := e.runDestinationTokenCollectionPhase(i, e.gameConstants.NumInitialDestinationTicketsOffered, e.gameConstants.NumInitialLongDestinationTicketsOffered, e.gameConstants.NumInitialDestinationTicketsPicked)
+		if err != nil {
+			e.applyIllegalMovePolicy(err)
+		}
 	}
 
 }
 
-func (e *Engine) runCollectionPhase() bool {
-
-	if e.OptimizerMode {
-		//	want to avoid panics in optimizer mode, so we don't let the player draw if the pile is empty
-		if len(e.pileOfTrainCards) == 0 && len(e.discardPileOfTrainCards) == 0{
-			return false
-		}
+func (e *Engine) pickupViolation(whichColor GameColor, howManyLeft int) *IllegalMoveError {
+	rule, broken := pickupRuleBroken(e.playerView(e.activePlayer), whichColor, howManyLeft)
+	if !broken {
+		return nil
+	}
+	if whichColor == Other {
+		return e.newIllegalMove(e.activePlayer, 0, rule, "draw from the deck")
+	}
+	if whichColor < 0 || int(whichColor) >= len(e.faceUpTrainCards) {
+		return e.newIllegalMove(e.activePlayer, 0, rule, "face up color "+strconv.Itoa(int(whichColor)))
 	}
+	return e.newIllegalMove(e.activePlayer, 0, rule, "face up "+e.stringColors[whichColor])
+}
 
-	//fmt.Println(e.pileOfTrainCards)
-	//fmt.Println(e.discardPileOfTrainCards)
-	//fmt.Println(e.OptimizerMode)
+//asks the active player which card he wants, and gives it to him: returns the card he asked for
+func (e *Engine) runSinglePickup(howManyLeft int) (GameColor, error) {
+	var whichColor GameColor
+	err := e.askUntilLegal(func() *IllegalMoveError {
+		whichColor = e.playerList[e.activePlayer].askPickup(howManyLeft, e.playerView(e.activePlayer))
+		return e.pickupViolation(whichColor, howManyLeft)
+	})
+	if err != nil {
+		return whichColor, err
+	}
 
-	whichColor := e.playerList[e.activePlayer].askPickup(2, e.faceUpTrainCards)
 	if whichColor != Other {
 		//	he wants a faceup card
-		if e.faceUpTrainCards[whichColor] <= 0 {
-			//	he cannot pick that card
-			panic("The player picked a missing color")
-		}
 		e.giveCardToPlayer(e.activePlayer, whichColor, false)
 		e.faceUpTrainCards[whichColor]--
-		e.faceUpTrainCards[e.drawTopTrainCard()]++
-
-		if whichColor == Rainbow {
-			//	picking a rainbow color costs 2, so you're done
-			return true
-		}
+		e.refillFaceUpTrainCards()
 	} else {
 		//	asking for a random card from the deck
 		e.giveCardToPlayer(e.activePlayer, e.drawTopTrainCard(), true)
 	}
+	return whichColor, nil
+}
+
+func (e *Engine) runCollectionPhase() (bool, error) {
 
 	if e.OptimizerMode {
 		//	want to avoid panics in optimizer mode, so we don't let the player draw if the pile is empty
-		if len(e.pileOfTrainCards) == 0 && len(e.discardPileOfTrainCards) == 0 {
-			return false
+		if len(e.pileOfTrainCards) == 0 && len(e.discardPileOfTrainCards) == 0{
+			return false, nil
 		}
 	}
 
-	//zap.S().Info(e.pileOfTrainCards)
-	//zap.S().Info(e.discardPileOfTrainCards)
 	//fmt.Println(e.pileOfTrainCards)
 	//fmt.Println(e.discardPileOfTrainCards)
+	//fmt.Println(e.OptimizerMode)
 
-
-	whichColor = e.playerList[e.activePlayer].askPickup(1, e.faceUpTrainCards)
+	whichColor, err := e.runSinglePickup(2)
+	if err != nil {
+		return false, err
+	}
 	if whichColor == Rainbow {
-		panic("The player picked a rainbow on his second turn")
+		//	picking a rainbow color costs 2, so you're done
+		return true, nil
 	}
 
-	if whichColor != Other {
-		//	he wants a faceup card
-		if e.faceUpTrainCards[whichColor] <= 0 {
-			//	he cannot pick that card
-			panic("The player picked a missing color")
+	if e.OptimizerMode {
+		//	want to avoid panics in optimizer mode, so we don't let the player draw if the pile is empty
+		if len(e.pileOfTrainCards) == 0 && len(e.discardPileOfTrainCards) == 0 {
+			return false, nil
 		}
-		e.giveCardToPlayer(e.activePlayer, whichColor, false)
-		e.faceUpTrainCards[whichColor]--
-		e.faceUpTrainCards[e.drawTopTrainCard()]++
-	} else {
-		//	asking for a random card from the deck
-		e.giveCardToPlayer(e.activePlayer, e.drawTopTrainCard(), true)
 	}
-	return true
-}
 
-func (e *Engine) logTrackLay(whichTrack int, whichColor GameColor, howManyColored, howManyRainbows int) {
-	if *toLog {
-		zap.L().Info("Engine: Player "+strconv.Itoa(e.activePlayer)+" has laid down a track from "+e.destinationNames[e.trackList[whichTrack].d1]+" to "+e.destinationNames[e.trackList[whichTrack].d2]+" costing "+strconv.Itoa(howManyColored)+" train cards of color "+e.stringColors[whichColor]+" and "+strconv.Itoa(howManyRainbows)+" rainbow Cards.",
-			zap.String("EVENT", "LAYING_TRACK"),
-			zap.String("DEST_1", e.destinationNames[e.trackList[whichTrack].d1]),
-			zap.String("DEST_2", e.destinationNames[e.trackList[whichTrack].d2]),
-			zap.String("PRIMARY_COLOR", e.stringColors[whichColor]),
-			zap.Int("NUM_PRIMARY", howManyColored),
-			zap.Int("NUM_RAINBOW", howManyRainbows),
-			zap.Int("PLAYER", e.activePlayer),
-		)
+	//zap.S().Info(e.pileOfTrainCards)
+	//zap.S().Info(e.discardPileOfTrainCards)
+	//fmt.Println(e.pileOfTrainCards)
+	//fmt.Println(e.discardPileOfTrainCards)
+
+	if len(legalPickups(e.playerView(e.activePlayer), 1)) == 0 {
+		//	nothing is left that can be picked as the second card, so the turn is over
+		return true, nil
 	}
 
-	if *toUseVisualizer {
-		server.BroadcastToNamespace("/", "ENGINE_UPDATE", "Engine: Player "+strconv.Itoa(e.activePlayer)+" has laid down a track from "+e.destinationNames[e.trackList[whichTrack].d1]+" to "+e.destinationNames[e.trackList[whichTrack].d2]+" costing "+strconv.Itoa(howManyColored)+" train cards of color "+e.stringColors[whichColor]+" and "+strconv.Itoa(howManyRainbows)+" rainbow Cards.")
+	_, err = e.runSinglePickup(1)
+	if err != nil {
+		return false, err
 	}
+	return true, nil
 }
 
-func (e *Engine) runTrackLayingPhase() bool {
-	whichTrack, whichColor := e.playerList[e.activePlayer].askTrackLay()
-	if e.trackStatus[whichTrack] != -1 {
-		panic("The player tried to place over an occupied track")
-	}
-	if whichColor == Rainbow {
-		panic("The player is trying to play rainbow: if you want to use only rainbows, select any other color by default, like red")
-	}
-	if e.trackList[whichTrack].c != whichColor && e.trackList[whichTrack].c != Other {
-		panic("The player is trying to play with the wrong color for the track")
+func (e *Engine) trackLayViolation(whichTrack int, payment Payment) *IllegalMoveError {
+	rule, broken := trackLayRuleBroken(e.playerView(e.activePlayer), whichTrack, payment)
+	if !broken {
+		return nil
 	}
-	if e.trackList[whichTrack].length > e.numTrains[e.activePlayer] {
-		panic("The player does not have enough trains to play this move")
+	whichColor := payment.Color
+	detail := "track " + strconv.Itoa(whichTrack) + " with color " + strconv.Itoa(int(whichColor))
+	if rule != RuleInvalidTrack && whichColor >= 0 && int(whichColor) < e.gameConstants.NumGameColors {
+		detail = "track " + strconv.Itoa(whichTrack) + " from " + e.destinationNames[e.trackList[whichTrack].d1] + " to " + e.destinationNames[e.trackList[whichTrack].d2] + " with color " + e.stringColors[whichColor]
 	}
-	if e.trackList[whichTrack].length > e.trainCardHands[e.activePlayer][whichColor]+e.trainCardHands[e.activePlayer][Rainbow] {
-		panic("The player does not have color cards to play this move")
+	detail += fmt.Sprintf(", paying %d of the color and %d rainbows", payment.NumColored, payment.NumRainbows)
+	return e.newIllegalMove(e.activePlayer, 1, rule, detail)
+}
+
+func (e *Engine) runTrackLayingPhase() error {
+	var whichTrack int
+	var payment Payment
+	err := e.askUntilLegal(func() *IllegalMoveError {
+		whichTrack, payment = e.playerList[e.activePlayer].askTrackLay()
+		return e.trackLayViolation(whichTrack, payment)
+	})
+	if err != nil {
+		return err
 	}
 
 	//	If we made it this far, I think we're good: do the move
 
+	whichColor := payment.Color
+	if e.trackList[whichTrack].kind == TunnelRoute {
+		extra, paid := e.runTunnel(whichTrack, payment)
+		if !paid {
+			//	the claim is given up: the player keeps its cards, and the turn is over
+			return nil
+		}
+		payment.NumColored += extra.NumColored
+		payment.NumRainbows += extra.NumRainbows
+	}
+	howManyColored, howManyRainbows := payment.NumColored, payment.NumRainbows
+
 	//	mark the track as occupied
 	e.trackStatus[whichTrack] = e.activePlayer
 
-	howManyColored, howManyRainbows := 0,0
 	// remove the cards
-	if e.trainCardHands[e.activePlayer][whichColor] >= e.trackList[whichTrack].length {
-		howManyColored = e.trackList[whichTrack].length
-		howManyRainbows = 0
-	} else {
-		//	gotta use up them rainbows
-		howManyColored = e.trainCardHands[e.activePlayer][whichColor]
-		howManyRainbows = e.trackList[whichTrack].length - e.trainCardHands[e.activePlayer][whichColor]
+	e.spendTrainCards(e.activePlayer, payment)
+
+	//remove the trains
+	e.numTrains[e.activePlayer] -= e.trackList[whichTrack].length
+
+	//tell all other players about the trackLay
+	for _,player := range e.playerList {
+		player.informTrackLay(e.activePlayer, whichTrack)
 	}
-	e.trainCardHands[e.activePlayer][whichColor] -= howManyColored
-	e.trainCardHands[e.activePlayer][Rainbow] -= howManyRainbows
 
-	for i:=0;i<howManyColored;i++ {
-		e.discardPileOfTrainCards = append(e.discardPileOfTrainCards, whichColor)
+	e.emit(TrackClaimed{
+		Player:      e.activePlayer,
+		Track:       whichTrack,
+		From:        e.trackList[whichTrack].d1,
+		To:          e.trackList[whichTrack].d2,
+		Color:       whichColor,
+		NumColored:  howManyColored,
+		NumRainbows: howManyRainbows,
+	})
+
+	return nil
+}
+
+//spendTrainCards takes a payment out of a player's hand and puts it on the discard pile
+func (e *Engine) spendTrainCards(playerNumber int, payment Payment) {
+	e.trainCardHands[playerNumber][payment.Color] -= payment.NumColored
+	e.trainCardHands[playerNumber][Rainbow] -= payment.NumRainbows
+
+	for i:=0;i<payment.NumColored;i++ {
+		e.discardPileOfTrainCards = append(e.discardPileOfTrainCards, payment.Color)
 	}
 
-	for i:=0;i<howManyRainbows;i++ {
+	for i:=0;i<payment.NumRainbows;i++ {
 		e.discardPileOfTrainCards = append(e.discardPileOfTrainCards, Rainbow)
 	}
 
-	//remove the trains
-	e.numTrains[e.activePlayer] -= e.trackList[whichTrack].length
+	//the face up cards may have run short while the deck was empty
+	e.refillFaceUpTrainCards()
+}
 
-	//tell all other players about the trackLay
-	for _,player := range e.playerList {
-		player.informTrackLay(e.activePlayer, whichTrack)
+//runTunnel turns over NumTunnelCards cards for a tunnel the active player claims with payment: every rainbow among them, and every card of the color paid with, costs one more card
+//it asks the player whether to pay those, if it can; a player that doesn't gives up the claim
+//the cards turned over are discarded either way
+func (e *Engine) runTunnel(whichTrack int, payment Payment) (Payment, bool) {
+	drawn := make([]GameColor, 0)
+	for len(drawn) < e.gameConstants.NumTunnelCards && e.numTrainCardsLeftToDraw() > 0 {
+		drawn = append(drawn, e.drawTopTrainCard())
+	}
+
+	numExtra := 0
+	for _, c := range drawn {
+		//	a track paid for with rainbows only is only matched by rainbows
+		if c == Rainbow || (c == payment.Color && payment.NumColored > 0) {
+			numExtra++
+		}
 	}
 
-	e.logTrackLay(whichTrack, whichColor, howManyColored, howManyRainbows)
+	//	the extra cards are paid out of what is left once the track itself is paid for
+	left := append([]int(nil), e.trainCardHands[e.activePlayer]...)
+	left[payment.Color] -= payment.NumColored
+	left[Rainbow] -= payment.NumRainbows
+	if payment.NumColored == 0 {
+		left[payment.Color] = 0
+	}
+	extra := payWith(left, payment.Color, numExtra, 0)
 
-	return e.numTrains[e.activePlayer] == 0
+	paid := numExtra == 0
+	if !paid && extra.NumRainbows <= left[Rainbow] {
+		paid = e.playerList[e.activePlayer].askTunnelPayment(whichTrack, append([]GameColor(nil), drawn...), extra)
+	}
+	e.discardPileOfTrainCards = append(e.discardPileOfTrainCards, drawn...)
+
+	track := e.trackList[whichTrack]
+	e.emit(TunnelCardsDrawn{Player: e.activePlayer, Track: whichTrack, From: track.d1, To: track.d2, Drawn: drawn, Extra: extra, Paid: paid})
+	return extra, paid
+}
+
+func (e *Engine) stationViolation(city Destination, payment Payment) *IllegalMoveError {
+	rule, broken := stationRuleBroken(e.playerView(e.activePlayer), city, payment)
+	if !broken {
+		return nil
+	}
+	whichColor := payment.Color
+	detail := "station in city " + strconv.Itoa(int(city)) + " with color " + strconv.Itoa(int(whichColor))
+	if city >= 0 && int(city) < len(e.destinationNames) && whichColor >= 0 && int(whichColor) < e.gameConstants.NumGameColors {
+		detail = "station in " + e.destinationNames[city] + " with color " + e.stringColors[whichColor]
+	}
+	detail += fmt.Sprintf(", paying %d of the color and %d rainbows", payment.NumColored, payment.NumRainbows)
+	return e.newIllegalMove(e.activePlayer, 3, rule, detail)
 }
 
-func (e *Engine) runDestinationTokenCollectionPhase(playerNumber, numToOffer, numToAccept int) {
+//runStationBuildingPhase asks the active player where to build a station, and builds it: the k'th station a player builds costs k cards of one color
+func (e *Engine) runStationBuildingPhase() error {
+	var city Destination
+	var payment Payment
+	err := e.askUntilLegal(func() *IllegalMoveError {
+		city, payment = e.playerList[e.activePlayer].askStation()
+		return e.stationViolation(city, payment)
+	})
+	if err != nil {
+		return err
+	}
+
+	e.stations[city] = e.activePlayer
+	e.stationsLeft[e.activePlayer]--
+	e.spendTrainCards(e.activePlayer, payment)
+
+	e.emit(StationBuilt{
+		Player:      e.activePlayer,
+		City:        city,
+		Color:       payment.Color,
+		NumColored:  payment.NumColored,
+		NumRainbows: payment.NumRainbows,
+	})
+	return nil
+}
+
+//noStations is the stations of a board with numCities cities before anybody builds one
+func noStations(numCities int) []int {
+	stations := make([]int, numCities)
+	for i := range stations {
+		stations[i] = -1
+	}
+	return stations
+}
+
+func (e *Engine) destinationTicketSelectionViolation(playerNumber int, acceptedList []int, numOffered, numToAccept int) *IllegalMoveError {
+	if rule, broken := ticketChoiceRuleBroken(acceptedList, numOffered, numToAccept); broken {
+		return e.newIllegalMove(playerNumber, 2, rule, "kept tickets "+fmt.Sprint(acceptedList)+" out of "+strconv.Itoa(numOffered))
+	}
+	return nil
+}
+
+//runDestinationTokenCollectionPhase offers a player numToOffer tickets, and numLongToOffer long ones after them, and gives it the ones it keeps
+//the short tickets it doesn't keep go back under the pile, and the long ones are out of the game
+func (e *Engine) runDestinationTokenCollectionPhase(playerNumber, numToOffer, numLongToOffer, numToAccept int) error {
 
 	//create a slice to offer
 	offerSlice := make([]DestinationTicket, 0)
@@ -408,156 +720,337 @@ func (e *Engine) runDestinationTokenCollectionPhase(playerNumber, numToOffer, nu
 	for j := 0; j < numToOffer; j++ {
 		ticket, ok := e.drawTopDestinationTicket()
 		if !ok {
-			panic("Not Enough Destination Tickets To Deal This Many To Each Player")
+			//	the pile ran out: offer whatever we could draw
+			break
 		}
 		offerSlice = append(offerSlice, ticket)
 	}
-
-	//	offer the slice
-	acceptedList := e.playerList[playerNumber].offerDestinationTickets(offerSlice, numToAccept)
-	if len(acceptedList) < numToAccept {
-		panic("The player didn't pick enough destination tickets")
+	numShort := len(offerSlice)
+	for j := 0; j < numLongToOffer; j++ {
+		ticket, ok := drawTopTicket(&e.pileOfLongDestinationTickets)
+		if !ok {
+			break
+		}
+		offerSlice = append(offerSlice, ticket)
 	}
+	if len(offerSlice) == 0 {
+		return e.newIllegalMove(playerNumber, 2, RuleNoDestinationTicketsLeft, "draw destination tickets")
+	}
+	numToAccept = min(numToAccept, len(offerSlice))
+	e.emit(TicketsOffered{Player: playerNumber, Tickets: offerSlice, MinKept: numToAccept})
 
-	alreadySeenAccepted := make([]int, 0) //used to prevent skirting the rules by printing duplicates
-
-	for _, accepted := range acceptedList {
-		if accepted < 0 || accepted >= len(offerSlice) || itemExists(alreadySeenAccepted, accepted) {
-			panic("The player gave invalid indices in selecting destination tickets")
+	//	offer the slice
+	var acceptedList []int
+	err := e.askUntilLegal(func() *IllegalMoveError {
+		//	the player gets a copy, so that what it does to the tickets can't change the ones it is given
+		acceptedList = e.playerList[playerNumber].offerDestinationTickets(append([]DestinationTicket(nil), offerSlice...), numToAccept)
+		return e.destinationTicketSelectionViolation(playerNumber, acceptedList, len(offerSlice), numToAccept)
+	})
+	if err != nil {
+		//	the offered tickets go back under their piles
+		for i, offered := range offerSlice {
+			if i < numShort {
+				e.putDestinationTicketBackInPile(offered)
+			} else {
+				e.pileOfLongDestinationTickets = append([]DestinationTicket{offered}, e.pileOfLongDestinationTickets...)
+			}
 		}
-		alreadySeenAccepted = append(alreadySeenAccepted, accepted)
+		return err
 	}
 
+	keptTickets := make([]DestinationTicket, 0)
 	for i, offered := range offerSlice {
 		if itemExists(acceptedList, i) {
 			//this is one of the destination cards he wants to pick
 			e.giveDestinationTicketToPlayer(playerNumber, offered)
-		} else {
+			keptTickets = append(keptTickets, offered)
+		} else if i < numShort {
 			//this is one of the ones he wants to not pick
 			e.putDestinationTicketBackInPile(offered)
 		}
 	}
+	e.emit(TicketsKept{Player: playerNumber, Tickets: keptTickets})
+	return nil
 }
 
 func (e *Engine) putDestinationTicketBackInPile(ticket DestinationTicket) {
 	e.pileOfDestinationTickets = append([]DestinationTicket{ticket}, e.pileOfDestinationTickets...)
 }
 
-func (e *Engine) logPlayerTurn() {
-	if *toLog {
-		zap.L().Info("Engine: It is the turn of player"+strconv.Itoa(e.activePlayer),
-			zap.String("EVENT", "TURN_START"),
-			zap.Int("PLAYER", e.activePlayer),
-		)
-	}
+func (e *Engine) startFinalRound() {
+	e.finalRoundTriggeredBy = e.activePlayer
+	e.turnsLeftInFinalRound = e.gameConstants.NumPlayers
 
-	if *toUseVisualizer {
-		server.BroadcastToNamespace("/", "ENGINE_UPDATE", "Engine: It is the turn of player"+strconv.Itoa(e.activePlayer))
+	e.emit(FinalRoundStarted{Player: e.finalRoundTriggeredBy, NumTrains: e.numTrains[e.finalRoundTriggeredBy]})
+
+	//tell everybody that these are their last turns
+	for _, pl := range e.playerList {
+		pl.informFinalRound(e.finalRoundTriggeredBy)
 	}
 }
 
-func (e *Engine) logPlayerDecision(whichMove int) {
-	if *toLog {
-		if whichMove == 0 {
-			zap.L().Info("Engine: Player "+strconv.Itoa(e.activePlayer)+" has decided to pick up cards",
-				zap.String("EVENT", "DECIDE_PICK_UP_CARDS"),
-				zap.Int("PLAYER", e.activePlayer),
-			)
-		} else if whichMove == 1 {
-			zap.L().Info("Engine: Player "+strconv.Itoa(e.activePlayer)+" has decided to lay tracks",
-				zap.String("EVENT", "DECIDE_LAY_TRACK"),
-				zap.Int("PLAYER", e.activePlayer),
-			)
-		} else if whichMove==2 {
-			zap.L().Info("Engine: Player "+strconv.Itoa(e.activePlayer)+" has decided to pick up destination tickets",
-				zap.String("EVENT", "DECIDE_PICK_UP_DESTINATION_TICKET"),
-				zap.Int("PLAYER", e.activePlayer),
-			)
-		}
-	}
+func (e *Engine) newIllegalMove(playerNumber, whichMove int, rule Rule, detail string) *IllegalMoveError {
+	return &IllegalMoveError{Player: playerNumber, Move: whichMove, Rule: rule, Detail: detail}
+}
+
+func (e *Engine) recordIllegalMove(err *IllegalMoveError, policy IllegalMovePolicy) {
+	violation := RuleViolation{Err: err, PolicyApplied: policy}
+	e.ruleViolations = append(e.ruleViolations, violation)
+	e.emit(IllegalMoveMade{Violation: violation})
+}
 
-	if *toUseVisualizer {
-		if whichMove == 0 {
-			server.BroadcastToNamespace("/", "ENGINE_UPDATE", "Engine: Player "+strconv.Itoa(e.activePlayer)+" has decided to pick up cards")
-		} else if whichMove == 1 {
-			server.BroadcastToNamespace("/", "ENGINE_UPDATE", "Engine: Player "+strconv.Itoa(e.activePlayer)+" has decided to lay tracks")
-		} else if whichMove==2 {
-			server.BroadcastToNamespace("/", "ENGINE_UPDATE", "Engine: Player "+strconv.Itoa(e.activePlayer)+" has decided to pick up destination tickets")
+//keeps asking a player for a decision until it is legal, or until the illegal move policy says to stop asking
+//ask should ask the player and return what was wrong with the answer, or nil if it was legal
+func (e *Engine) askUntilLegal(ask func() *IllegalMoveError) error {
+	for attempt := 0; ; attempt++ {
+		illegalMove := ask()
+		if illegalMove == nil {
+			return nil
 		}
+		if e.IllegalMovePolicy != RetryIllegalMove || attempt >= NUMILLEGALMOVERETRIES {
+			return illegalMove
+		}
+		e.recordIllegalMove(illegalMove, RetryIllegalMove)
 	}
 }
 
+//called once a player's illegal move was not fixed by retrying: the rest of the turn is lost, and the policy may cost more
+func (e *Engine) applyIllegalMovePolicy(err error) {
+	illegalMove, ok := err.(*IllegalMoveError)
+	if !ok {
+		panic(err)
+	}
 
-func (e *Engine) runSingleTurn() bool {
-
-	e.logPlayerTurn()
+	switch e.IllegalMovePolicy {
+	case RetryIllegalMove, ForfeitTurnOnIllegalMove:
+		e.recordIllegalMove(illegalMove, ForfeitTurnOnIllegalMove)
+	case DisqualifyOnIllegalMove:
+		e.recordIllegalMove(illegalMove, DisqualifyOnIllegalMove)
+		e.disqualified[illegalMove.Player] = true
+	case AbortGameOnIllegalMove:
+		e.recordIllegalMove(illegalMove, AbortGameOnIllegalMove)
+		e.aborted = true
+	}
+}
 
-	//end condition: since this turn is starting with a player with less than two trains, he must have reached here on the previous move
-	if e.numTrains[e.activePlayer] <= 2 {
+//illegalMovesEndedGame says whether the illegal move policy has ended the game, and notes why
+func (e *Engine) illegalMovesEndedGame() bool {
+	if e.aborted {
+		e.endReason = EndedAborted
+		return true
+	}
+	if e.numPlayersLeft() == 0 {
+		e.endReason = EndedAllDisqualified
 		return true
 	}
+	return false
+}
+
+func (e *Engine) numPlayersLeft() int {
+	playersLeft := 0
+	for _, isDisqualified := range e.disqualified {
+		if !isDisqualified {
+			playersLeft++
+		}
+	}
+	return playersLeft
+}
 
-	//first, inform the player of the game state
-	e.playerList[e.activePlayer].informStatus(e.trackStatus, e.faceUpTrainCards)
+func (e *Engine) moveChoiceViolation(whichMove int) *IllegalMoveError {
+	rule, broken := moveRuleBroken(e.playerView(e.activePlayer), whichMove)
+	if !broken {
+		return nil
+	}
+	if rule == RuleNoTrainCardsLeft {
+		return e.newIllegalMove(e.activePlayer, whichMove, rule, "pick up cards with none left to pick")
+	}
+	if rule == RuleNoDestinationTicketsLeft {
+		return e.newIllegalMove(e.activePlayer, whichMove, rule, "draw destination tickets")
+	}
+	return e.newIllegalMove(e.activePlayer, whichMove, rule, "move "+strconv.Itoa(whichMove))
+}
 
-	whichMove := e.playerList[e.activePlayer].askMove()
-	//first, ask the guy whose turn it is what he wants to d
-	e.logPlayerDecision(whichMove)
+func (e *Engine) runSingleTurn() bool {
 
+	e.emit(TurnStarted{Player: e.activePlayer})
+	e.numTurns++
 
-	if whichMove == 0 {
-		// let him pick up cards
-		if !e.runCollectionPhase(){
-			e.falseMoveCount++
-		} else {
-			e.falseMoveCount = 0
-		}
-	} else if whichMove == 1 {
-		//ask them to put down some tracks
-		e.falseMoveCount=0
-		e.runTrackLayingPhase()
-	} else if whichMove == 2 {
-		//finally ask them to decide and pick some destination tokens
-		e.falseMoveCount=0
-		e.runDestinationTokenCollectionPhase(e.activePlayer, e.gameConstants.NumDestinationTicketsOffered, e.gameConstants.NumDestinationTicketsPicked)
+	var err error
+	if e.disqualified[e.activePlayer] {
+		//	disqualified players sit out the rest of the game
+		e.falseMoveCount++
+	} else if view := e.playerView(e.activePlayer); len(LegalMoves(view).Moves) == 0 {
+		//	nothing can be drawn or claimed, so the turn passes without asking, and without counting against the player
+		e.falseMoveCount++
 	} else {
-		panic("Invalid move choice")
+		//first, inform the player of the game state
+		e.playerList[e.activePlayer].informStatus(view)
+
+		//first, ask the guy whose turn it is what he wants to do
+		var whichMove int
+		err = e.askUntilLegal(func() *IllegalMoveError {
+			whichMove = e.playerList[e.activePlayer].askMove()
+			return e.moveChoiceViolation(whichMove)
+		})
+
+		if err == nil {
+			e.emit(MoveChosen{Player: e.activePlayer, Move: whichMove})
+
+			if whichMove == 0 {
+				// let him pick up cards
+				var pickedUp bool
+				pickedUp, err = e.runCollectionPhase()
+				if pickedUp {
+					e.falseMoveCount = 0
+				} else if err == nil {
+					//	an illegal pickup is counted below, with the other illegal moves
+					e.falseMoveCount++
+				}
+			} else if whichMove == 1 {
+				//ask them to put down some tracks
+				e.falseMoveCount = 0
+				err = e.runTrackLayingPhase()
+			} else if whichMove == 2 {
+				//finally ask them to decide and pick some destination tokens
+				e.falseMoveCount = 0
+				err = e.runDestinationTokenCollectionPhase(e.activePlayer, e.gameConstants.NumDestinationTicketsOffered, 0, e.gameConstants.NumDestinationTicketsPicked)
+			} else if whichMove == 3 {
+				//or build a station, under the Europe rules
+				e.falseMoveCount = 0
+				err = e.runStationBuildingPhase()
+			}
+		}
+	}
+
+	if err != nil {
+		//	the turn ended with an illegal move, which counts as a turn without progress
+		e.applyIllegalMovePolicy(err)
+		e.falseMoveCount++
+		if e.illegalMovesEndedGame() {
+			return true
+		}
 	}
 
-	if e.falseMoveCount == e.gameConstants.NumPlayers+1 {
+	if e.falseMoveCount >= e.gameConstants.NumPlayers+1 {
 		//everybody keeps asking to pick up cards!
+		e.endReason = EndedStalled
 		return true
 	}
 
+	//end condition: once a player runs low on trains, everybody gets one more turn, and the game ends after that player's last turn
+	if e.finalRoundTriggeredBy != -1 {
+		e.turnsLeftInFinalRound--
+		if e.turnsLeftInFinalRound == 0 {
+			e.endReason = EndedAfterFinalRound
+			return true
+		}
+	} else if e.numTrains[e.activePlayer] <= e.gameConstants.NumTrainsForFinalRound {
+		e.startFinalRound()
+	}
+
 	//next player
 	e.activePlayer++
 	e.activePlayer %= e.gameConstants.NumPlayers
 	return false
 }
 
-func (e *Engine) determinePlayerScore(playerNumber int) int {
-	score := 0
+//determinePlayerResult scores a player's routes and tickets; the longest path bonus depends on the other players, so it is left to determineWinners
+func (e *Engine) determinePlayerResult(playerNumber int) PlayerResult {
+	result := PlayerResult{
+		TrainsLeft:   e.numTrains[playerNumber],
+		Disqualified: e.disqualified[playerNumber],
+	}
+	result.LongestPath, result.LongestPathTracks = longestTrail(e.trackList, e.trackStatus, playerNumber)
 
 	// add all the scores for paths
 	for i, status := range e.trackStatus {
 		if status == playerNumber {
-			score += e.gameConstants.routeLengthScores[e.trackList[i].length]
+			result.RoutePoints += e.gameConstants.routeLengthScores[e.trackList[i].length]
 		}
 	}
 
-	//	add or subtract the score for each destination ticket
+	//	add or subtract the score for each destination ticket, with the tracks the player's stations borrow
+	result.BorrowedTracks = e.bestBorrowedTracks(playerNumber)
+	borrowed := e.borrowedTrackSet(result.BorrowedTracks)
 	for _, ticket := range e.destinationTicketHands[playerNumber] {
-		if e.isConnected(ticket.d1, ticket.d2, playerNumber) {
-			score += ticket.points
-		} else {
-			score -= ticket.points
+		ticketResult := TicketResult{Ticket: ticket, Completed: e.isConnected(ticket.d1, ticket.d2, playerNumber, borrowed), Points: -ticket.points}
+		if ticketResult.Completed {
+			ticketResult.Points = ticket.points
+		}
+		result.Tickets = append(result.Tickets, ticketResult)
+		result.TicketPoints += ticketResult.Points
+	}
+
+	//	and the points for the stations the player didn't build
+	result.StationPoints = e.gameConstants.StationScore * e.stationsLeft[playerNumber]
+
+	result.Score = result.RoutePoints + result.TicketPoints + result.StationPoints
+	return result
+}
+
+//bestBorrowedTracks picks, for each of the player's stations, a track of another player at its city to use for the player's tickets, so that they score the most
+//every combination is tried: a player has only a few stations, and only a few tracks meet at a city
+//stations only borrow tracks when that completes more tickets, so the result may be empty
+func (e *Engine) bestBorrowedTracks(playerNumber int) []int {
+	choices := make([][]int, 0) //the tracks each station could borrow
+	for city, owner := range e.stations {
+		if owner != playerNumber {
+			continue
+		}
+		tracks := make([]int, 0)
+		for _, track := range e.adjacencyList[city] {
+			if e.trackStatus[track] >= 0 && e.trackStatus[track] != playerNumber {
+				tracks = append(tracks, track)
+			}
+		}
+		if len(tracks) > 0 {
+			choices = append(choices, tracks)
 		}
 	}
 
-	return score
+	best, bestPoints := []int{}, e.ticketPoints(playerNumber, nil)
+	borrowed := make([]int, len(choices))
+	var try func(station int)
+	try = func(station int) {
+		if station == len(choices) {
+			if points := e.ticketPoints(playerNumber, e.borrowedTrackSet(borrowed)); points > bestPoints {
+				best, bestPoints = append([]int(nil), borrowed...), points
+			}
+			return
+		}
+		for _, track := range choices[station] {
+			borrowed[station] = track
+			try(station + 1)
+		}
+	}
+	try(0)
+	return best
+}
+
+//borrowedTrackSet marks the borrowed tracks, indexed by track
+func (e *Engine) borrowedTrackSet(borrowedTracks []int) []bool {
+	borrowed := make([]bool, len(e.trackList))
+	for _, track := range borrowedTracks {
+		borrowed[track] = true
+	}
+	return borrowed
 }
 
-func (e *Engine) dfs(src, dst Destination, playerNumber int, seen []bool) bool {
+//ticketPoints adds up the points of a player's destination tickets, using the borrowed tracks as if they were the player's own
+func (e *Engine) ticketPoints(playerNumber int, borrowed []bool) int {
+	points := 0
+	for _, ticket := range e.destinationTicketHands[playerNumber] {
+		if e.isConnected(ticket.d1, ticket.d2, playerNumber, borrowed) {
+			points += ticket.points
+		} else {
+			points -= ticket.points
+		}
+	}
+	return points
+}
+
+//dfs looks for a path from src to dst over the player's tracks, and the borrowed tracks; borrowed may be nil
+func (e *Engine) dfs(src, dst Destination, playerNumber int, borrowed []bool, seen []bool) bool {
 
 	if src == dst {
 		return true
@@ -568,7 +1061,7 @@ func (e *Engine) dfs(src, dst Destination, playerNumber int, seen []bool) bool {
 	var otherDestination Destination
 
 	for _, edgeIndex := range e.adjacencyList[src] {
-		if e.trackStatus[edgeIndex] != playerNumber {
+		if e.trackStatus[edgeIndex] != playerNumber && (borrowed == nil || !borrowed[edgeIndex]) {
 			continue
 		}
 
@@ -578,17 +1071,18 @@ func (e *Engine) dfs(src, dst Destination, playerNumber int, seen []bool) bool {
 			continue
 		}
 
-		if e.dfs(otherDestination, dst, playerNumber, seen) {
+		if e.dfs(otherDestination, dst, playerNumber, borrowed, seen) {
 			return true
 		}
 	}
 	return false
 }
 
-func (e *Engine) isConnected(d1, d2 Destination, playerNumber int) bool {
+//isConnected says whether the player's tracks connect two cities, with the help of the borrowed tracks, indexed by track; borrowed may be nil
+func (e *Engine) isConnected(d1, d2 Destination, playerNumber int, borrowed []bool) bool {
 	seen := make([]bool, e.gameConstants.NumDestinations)
 
-	return e.dfs(d1, d2, playerNumber, seen)
+	return e.dfs(d1, d2, playerNumber, borrowed, seen)
 }
 
 func (e *Engine) getOtherDestination(d Destination, t Track) Destination {
@@ -601,54 +1095,12 @@ func (e *Engine) getOtherDestination(d Destination, t Track) Destination {
 	}
 }
 
-func (e *Engine) computeLongestPathRecursive(x Destination, currLen int, adjList [][]int, seenEdge []bool, toUpdate *int) {
-	if currLen > (*toUpdate) {
-		*toUpdate = currLen
-	}
-
-	for _, edgeIndex := range adjList[x] {
-		if !seenEdge[edgeIndex] {
-			seenEdge[edgeIndex] = true
-			e.computeLongestPathRecursive(e.getOtherDestination(x, e.trackList[edgeIndex]), currLen+e.trackList[edgeIndex].length, adjList, seenEdge, toUpdate)
-			seenEdge[edgeIndex] = false
-		}
-	}
-}
-
-func (e *Engine) determineLongestPathForPlayer(playerNumber int) int {
-	//	There are two ways I thought of doing this and I'm not sure which is better
-	//	Way #1: iterate over all subsets of edges, and see if you can get a set of edges which induces a subgraph which is connected and eulerean
-	//	The check for eulerean is simple, the check for connectedness is dfs
-	//	Way #2: from all starting points, repeatedly try all edges, update ans=max(ans,currPathLen)
-	//	 I'm not even sure if way #2 is correct
-
-	//	First, create a playerAdjList, which selects from adjList only those which belong to the player
-	playerAdjList := make([][]int, e.gameConstants.NumDestinations)
-	for i, adj := range e.adjacencyList {
-		for _, edge := range adj {
-			if e.trackStatus[edge] == playerNumber {
-				playerAdjList[i] = append(playerAdjList[i], edge)
-			}
-		}
-	}
-
-	seenEdge := make([]bool, e.gameConstants.NumTracks)
-
-	ans := 0
-	//	now, from each starting point, run the recursive computer
-	for i := 0; i < e.gameConstants.NumDestinations; i++ {
-		e.computeLongestPathRecursive(Destination(i), 0, playerAdjList, seenEdge, &ans)
-	}
-
-	return ans
-}
-
-func (e *Engine) getLongestPathPlayers() []int {
+func (e *Engine) getLongestPathPlayers(results []PlayerResult) []int {
 	longestPathers := make([]int, 0)
 	currLongestPathLength := 0
 
 	for i := range e.playerList {
-		sc := e.determineLongestPathForPlayer(i)
+		sc := results[i].LongestPath
 		//fmt.Println("Player", i, " Path Length ",sc)
 		if sc > currLongestPathLength {
 			currLongestPathLength = sc
@@ -662,37 +1114,53 @@ func (e *Engine) getLongestPathPlayers() []int {
 	return longestPathers
 }
 
-func (e *Engine) logPlayerScore(i,sc int) {
-
-	fmt.Println("Player", i, "scored", sc)
-
-	if *toLog {
-		zap.L().Info("Engine: The score of player "+strconv.Itoa(i)+" is "+strconv.Itoa(sc),
-			zap.String("EVENT", "SCORE_COMPUTE"),
-			zap.Int("PLAYER", i),
-			zap.Int("SCORE", sc),
-		)
+//getGlobetrotters returns the players who completed the most destination tickets, if anybody completed one
+func (e *Engine) getGlobetrotters(results []PlayerResult) []int {
+	players := make([]int, len(results))
+	for i := range players {
+		players[i] = i
 	}
-
-	if *toUseVisualizer {
-		server.BroadcastToNamespace("/", "ENGINE_UPDATE", "Engine: The score of player "+strconv.Itoa(i)+" is "+strconv.Itoa(sc))
+	globetrotters := mostBy(players, func(p int) int { return results[p].TicketsCompleted() })
+	if len(globetrotters) == 0 || results[globetrotters[0]].TicketsCompleted() == 0 {
+		return nil
 	}
-
+	return globetrotters
 }
 
-func (e *Engine) determineWinners() []int {
+//determineWinners scores every player, and returns how each of them did along with the winners
+func (e *Engine) determineWinners() ([]PlayerResult, []int) {
 	winners := make([]int, 0)
 	currBestScore := -1000000
 
+	results := make([]PlayerResult, len(e.playerList))
+	for i := range e.playerList {
+		results[i] = e.determinePlayerResult(i)
+	}
+
 	//figure out which player(s) have longest paths
-	longestPathPlayers := e.getLongestPathPlayers()
+	longestPathPlayers := e.getLongestPathPlayers(results)
+	globetrotters := e.getGlobetrotters(results)
 
+	timings := playerTimings(e.playerList)
+	scores := make([]int, len(e.playerList))
 	for i := range e.playerList {
-		sc := e.determinePlayerScore(i)
 		if itemExists(longestPathPlayers, i) {
-			sc += e.gameConstants.LongestPathScore
+			results[i].LongestPathBonus = e.gameConstants.LongestPathScore
+			results[i].Score += results[i].LongestPathBonus
+		}
+		if itemExists(globetrotters, i) {
+			results[i].GlobetrotterBonus = e.gameConstants.GlobetrotterScore
+			results[i].Score += results[i].GlobetrotterBonus
+		}
+		if timings != nil {
+			results[i].Timing = timings[i]
+		}
+		sc := results[i].Score
+		scores[i] = sc
+		if e.disqualified[i] {
+			//	disqualified players cannot win
+			continue
 		}
-		e.logPlayerScore(i, sc)
 		if sc > currBestScore {
 			currBestScore = sc
 			winners = nil
@@ -702,84 +1170,84 @@ func (e *Engine) determineWinners() []int {
 		}
 	}
 
-	if len(winners) == 0 {
+	if len(winners) == 0 && e.numPlayersLeft() > 0 {
 		panic("WTF")
 	}
 
-	return winners
-}
-
-func (e *Engine) updateGraphVizString(vizString *string) bool {
-	newString := e.getGraphVizString()
-	if newString != *vizString {
-		*vizString = newString
-		return true
+	if !e.KeepTies {
+		winners = breakTies(winners, results)
 	}
-	return false
-}
-
-func (e *Engine) doGraphStuff(vizString *string) {
-	if toGenerateGraphs {
-		if e.updateGraphVizString(vizString) {
 
-			if *toLog && !*consoleView {
-				zap.L().Info("Logging Graph",
-					zap.String("EVENT", "GRAPH"),
-					zap.String("GRAPH", *vizString),
-				)
-			}
-
-			if *toUseVisualizer {
+	longestPaths := make([][]int, len(results))
+	for i, result := range results {
+		longestPaths[i] = result.LongestPathTracks
+	}
+	e.emit(GameScored{Scores: scores, Winners: winners, Timings: timings, LongestPaths: longestPaths})
 
-				//	write graph to file
-				e.writeGraphToFile("visualizer/graph_pics/graph", *vizString)
-				//	generate png
-				cmd := exec.Command("neato", "visualizer/graph_pics/graph", "-Tpng")
-				out,err := cmd.CombinedOutput()
-				if err != nil {
-					zap.S().Fatal(err)
-				}
-				//fmt.Println(out)
-				file, err := os.Create("visualizer/graph_pics/graph.png")
-				if err != nil {
-					panic("failed creating file")
-				}
-				defer file.Close()
-				_, err = file.Write(out)
-				if err != nil {
-					panic("failed writing to file")
-				}
+	return results, winners
+}
 
-				server.BroadcastToNamespace("/", "GRAPH_UPDATE")
+//breakTies applies the official tie breakers to the players tied on points: the most completed destination tickets wins, then the longest continuous path
+//the players still tied after both share the win
+func breakTies(tied []int, results []PlayerResult) []int {
+	tied = mostBy(tied, func(p int) int { return results[p].TicketsCompleted() })
+	return mostBy(tied, func(p int) int { return results[p].LongestPath })
+}
 
-				//time.Sleep(200*time.Millisecond)
-			}
+//mostBy returns the players with the most of something
+func mostBy(players []int, howMuch func(player int) int) []int {
+	most := make([]int, 0)
+	for _, p := range players {
+		if len(most) > 0 && howMuch(p) < howMuch(most[0]) {
+			continue
 		}
+		if len(most) > 0 && howMuch(p) > howMuch(most[0]) {
+			most = most[:0]
+		}
+		most = append(most, p)
 	}
+	return most
 }
 
-func (e *Engine) runGame(playerList []Player, constants GameConstants) []int {
+func (e *Engine) runGame(playerList []Player, constants GameConstants) GameResult {
+	//	if anything blows up, make sure we know which seed to replay
+	defer func() {
+		if r := recover(); r != nil {
+			panic(fmt.Sprintf("the game with seed %d crashed: %v", e.Seed, r))
+		}
+	}()
+
 	//initialize
 	e.initializeGame(playerList, constants)
 
-	gameOver := false
-	graphVizString := ""
+	return e.playUntilGameOver()
+}
 
-	e.doGraphStuff(&graphVizString)
+//runs turns until the game is over, and returns how it ended
+func (e *Engine) playUntilGameOver() GameResult {
+	//	an illegal move while dealing the initial destination tickets may already have ended the game
+	gameOver := e.illegalMovesEndedGame()
 
-	moveNumber := 0
 	//run turns until the game is over
-
 	for !gameOver {
-		moveNumber++
 		gameOver = e.runSingleTurn()
+	}
 
-		//log the graph
-		e.doGraphStuff(&graphVizString)
+	result := GameResult{
+		Seed:           e.Seed,
+		Winners:        []int{},
+		NumTurns:       e.numTurns,
+		EndReason:      e.endReason,
+		RuleViolations: append([]RuleViolation(nil), e.ruleViolations...),
+	}
+	if e.aborted {
+		//	an aborted game has no winner
+		return result
 	}
 
 	//determine the Winner
-	return e.determineWinners()
+	result.Players, result.Winners = e.determineWinners()
+	return result
 }
 
 func (e *Engine) writeGraphToFile(filename string, vizString string) {
@@ -795,7 +1263,14 @@ func (e *Engine) writeGraphToFile(filename string, vizString string) {
 
 }
 
-func (e *Engine) getGraphVizString() string {
+//getGraphVizString renders the board, drawing the tracks of the given longest paths wider
+func (e *Engine) getGraphVizString(longestPaths [][]int) string {
+	onLongestPath := make([]bool, len(e.trackList))
+	for _, path := range longestPaths {
+		for _, track := range path {
+			onLongestPath[track] = true
+		}
+	}
 
 	//for graphviz testing purposes
 	//for i:=0;i<10;i++ {
@@ -811,7 +1286,7 @@ func (e *Engine) getGraphVizString() string {
 	graphString += " splines=spline"
 
 
-	for dest,pos := range mapPositions {
+	for dest,pos := range e.board().Positions {
 		graphString += "\t"
 		graphString += dest
 		graphString += " [ pos=\""
@@ -820,10 +1295,16 @@ func (e *Engine) getGraphVizString() string {
 		graphString += "\n"
 	}
 
-	for _,dest := range e.destinationNames {
+	for city,dest := range e.destinationNames {
 		graphString += "\t"
 		graphString += dest
-		graphString += " [ fontsize=17 ]"
+		//	the ids name the elements of the svg, which the visualizer lets a human player click
+		graphString += " [ id=city" + strconv.Itoa(city) + ", fontsize=17"
+		if e.stations[city] != -1 {
+			//	a city with a station is filled with the color of its owner
+			graphString += ", style=filled, fillcolor=" + e.stringColors[e.stations[city]]
+		}
+		graphString += " ]"
 		graphString += "\n"
 
 	}
@@ -833,8 +1314,8 @@ func (e *Engine) getGraphVizString() string {
 		graphString += e.destinationNames[track.d1]
 		graphString += " -- "
 		graphString += e.destinationNames[track.d2]
-		graphString += " [ len=" + strconv.Itoa(track.length) + ","
-		graphString += " label=\"" + strconv.Itoa(track.idx) + " " + strconv.Itoa(track.length) + "\","
+		graphString += " [ id=track" + strconv.Itoa(i) + ", len=" + strconv.Itoa(track.length) + ","
+		graphString += " label=\"" + strconv.Itoa(track.idx) + " " + strconv.Itoa(track.length) + trackKindLabel(track) + "\","
 		graphString += " fontsize= 20,"
 
 		if e.trackStatus[i] == -1 {
@@ -847,7 +1328,11 @@ func (e *Engine) getGraphVizString() string {
 			}
 		} else {
 			//there is a player on the track
-			graphString += "style=bold,penwidth=7.0,color="
+			if onLongestPath[i] {
+				graphString += "style=bold,penwidth=14.0,color="
+			} else {
+				graphString += "style=bold,penwidth=7.0,color="
+			}
 			if e.trackStatus[i] == int(Rainbow) {
 				graphString += "red:green:yellow:blue:orange:purple"
 			} else {
@@ -861,3 +1346,14 @@ func (e *Engine) getGraphVizString() string {
 	graphString += "}"
 	return graphString
 }
+
+//trackKindLabel marks tunnels and ferries in the graph, with the locomotives a ferry takes
+func trackKindLabel(track Track) string {
+	switch track.kind {
+	case TunnelRoute:
+		return " tunnel"
+	case FerryRoute:
+		return " ferry " + strconv.Itoa(track.locomotives)
+	}
+	return ""
+}
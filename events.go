@@ -0,0 +1,120 @@
+package main
+
+//GameEvent is something that happened in a game, as seen by an omniscient observer
+//observers tell the events apart with a type switch
+type GameEvent interface {
+	isGameEvent()
+}
+
+//Observer is told about every event of the games it is registered with, in the order they happen
+//observers are called on the goroutine running the game, so an observer registered with several engines must be safe for concurrent use
+type Observer interface {
+	observe(event GameEvent)
+}
+
+//GameStarted is the first event of a game
+type GameStarted struct {
+	Seed             int64
+	NumPlayers       int
+	DestinationNames []string //the names of the cities of the board, indexed by Destination
+}
+
+//TurnStarted is sent at the start of every turn, including the turns of disqualified players
+type TurnStarted struct {
+	Player int
+}
+
+//MoveChosen is sent once a player has picked a legal move: 0 is pick up cards, 1 is lay track, 2 is pick destination tickets, 3 is build a station
+type MoveChosen struct {
+	Player int
+	Move   int
+}
+
+//CardDrawn is sent whenever a player gets a train card
+type CardDrawn struct {
+	Player int
+	Color  GameColor
+	Hidden bool //the other players were not told the color, since it came from the deck
+}
+
+//FaceUpCardsReshuffled is sent when too many face up cards are rainbows, and all of them are replaced
+type FaceUpCardsReshuffled struct {
+	NumRainbows int
+}
+
+//TicketsOffered is sent when a player is shown destination tickets to choose from
+type TicketsOffered struct {
+	Player  int
+	Tickets []DestinationTicket
+	MinKept int //how many of the tickets the player must keep
+}
+
+//TicketsKept is sent once a player has chosen which of the offered destination tickets to keep
+type TicketsKept struct {
+	Player  int
+	Tickets []DestinationTicket
+}
+
+//TrackClaimed is sent when a player lays down a track
+type TrackClaimed struct {
+	Player      int
+	Track       int
+	From, To    Destination
+	Color       GameColor //the color the player paid with
+	NumColored  int
+	NumRainbows int
+}
+
+//TunnelCardsDrawn is sent when a player claims a tunnel, and the cards turned over for it call for Extra cards more
+//if the player didn't pay them, or couldn't, the claim was given up; otherwise TrackClaimed follows
+type TunnelCardsDrawn struct {
+	Player   int
+	Track    int
+	From, To Destination
+	Drawn    []GameColor
+	Extra    Payment //the cards the tunnel costs on top of its length, as the player would have paid them
+	Paid     bool
+}
+
+//StationBuilt is sent when a player builds a station, under the Europe rules
+type StationBuilt struct {
+	Player      int
+	City        Destination
+	Color       GameColor //the color the player paid with
+	NumColored  int
+	NumRainbows int
+}
+
+//FinalRoundStarted is sent when a player runs low on trains, and everybody gets one last turn
+type FinalRoundStarted struct {
+	Player    int //the player who ran low on trains
+	NumTrains int
+}
+
+//IllegalMoveMade is sent whenever the engine refuses a move
+type IllegalMoveMade struct {
+	Violation RuleViolation
+}
+
+//GameScored is the last event of a game that was played to the end; aborted games are never scored
+type GameScored struct {
+	Scores  []int //the final score of every player, including the longest path bonus
+	Winners []int
+	Timings []PlayerTiming //how long every player took, if the players had time limits
+
+	LongestPaths [][]int //the tracks of every player's longest continuous path, in order from one end to the other
+}
+
+func (GameStarted) isGameEvent()           {}
+func (TurnStarted) isGameEvent()           {}
+func (MoveChosen) isGameEvent()            {}
+func (CardDrawn) isGameEvent()             {}
+func (FaceUpCardsReshuffled) isGameEvent() {}
+func (TicketsOffered) isGameEvent()        {}
+func (TicketsKept) isGameEvent()           {}
+func (TrackClaimed) isGameEvent()          {}
+func (TunnelCardsDrawn) isGameEvent()      {}
+func (StationBuilt) isGameEvent()          {}
+func (FinalRoundStarted) isGameEvent()     {}
+func (IllegalMoveMade) isGameEvent()       {}
+func (GameScored) isGameEvent()            {}
@@ -6,7 +6,9 @@ const NUMDESTINATIONS = 36
 const NUMCOLORCARDS = 12
 const NUMRAINBOWCARDS = 14
 const NUMSTARTINGTRAINS = 48
+const NUMTRAINSFORFINALROUND = 2 //once a player has this many trains or fewer, everybody gets one last turn
 const NUMFACEUPTRAINCARDS = 5
+const NUMFACEUPRAINBOWSFORRESHUFFLE = 3 //if this many face up cards are rainbows, all of them are discarded and replaced
 const NUMGAMECOLORS = 9
 const NUMINITIALTRAINCARDSDEALT = 4
 const NUMINITIALDESTINATIONTICKETSOFFERED = 3
@@ -14,9 +16,46 @@ const NUMINITIALDESTINATIONTICKETSPICKED = 2
 const NUMDESTINATIONTICKETSOFFERED = 3
 const NUMDESTINATIONTICKETSPICKED = 1
 const LONGESTPATHSCORE = 10
+const NUMILLEGALMOVERETRIES = 3 //how many times a player is asked again after an illegal move, under the retry policy
+const DOUBLEROUTEMINPLAYERS = 4 //with fewer players than this, only one half of a double route can be used
+const MINPLAYERS = 2
+const MAXPLAYERS = 5
+
+const CLOSEDTRACK = -2 //track status shown to a player for a free track that the double route rules don't let them claim
 
 var routeLengthScores = []int{0, 1, 2, 4, 7, 10, 15, 21}
 
+//playerCountRules are the standard game's changes for some numbers of players: apart from the double routes, which DoubleRouteMinPlayers covers, there are none
+var playerCountRules = []PlayerCountRule{}
+
+//defaultGameConstants returns the constants of the standard game; NumPlayers and what the constants say about the board are filled in by the engine, which also applies the rules for the number of players
+func defaultGameConstants() GameConstants {
+	return GameConstants{
+		NumColorCards:                       NUMCOLORCARDS,
+		NumRainbowCards:                     NUMRAINBOWCARDS,
+		NumStartingTrains:                   NUMSTARTINGTRAINS,
+		NumTrainsForFinalRound:              NUMTRAINSFORFINALROUND,
+		NumFaceUpTrainCards:                 NUMFACEUPTRAINCARDS,
+		NumFaceUpRainbowsForReshuffle:       NUMFACEUPRAINBOWSFORRESHUFFLE,
+		NumGameColors:                       NUMGAMECOLORS,
+		NumInitialTrainCardsDealt:           NUMINITIALTRAINCARDSDEALT,
+		NumInitialDestinationTicketsOffered: NUMINITIALDESTINATIONTICKETSOFFERED,
+		NumInitialDestinationTicketsPicked:  NUMINITIALDESTINATIONTICKETSPICKED,
+		NumDestinationTicketsOffered:        NUMDESTINATIONTICKETSOFFERED,
+		NumDestinationTicketsPicked:         NUMDESTINATIONTICKETSPICKED,
+		LongestPathScore:                    LONGESTPATHSCORE,
+		DoubleRouteMinPlayers:               DOUBLEROUTEMINPLAYERS,
+		NumTunnelCards:                      NUMTUNNELCARDS,
+		MinPlayers:                          MINPLAYERS,
+		MaxPlayers:                          MAXPLAYERS,
+		PlayerCountRules:                    playerCountRules,
+		NumPlayers:                          0,
+		NumTracks:                           0,
+		NumDestinations:                     NUMDESTINATIONS,
+		routeLengthScores:                   routeLengthScores,
+	}
+}
+
 // ['Atlanta', 'Boston', 'Calgary', 'Charleston', 'Chicago', 'Dallas', 'Denver', 'Duluth', 'El Paso', 'Helena', 'Houston', 'Kansas City', 'Las Vegas', 'Little Rock', 'Los Angeles', 'Miami', 'Montreal', 'Nashville', 'New Orleans', 'New York', 'Oklahoma City', 'Omaha', 'Phoenix', 'Pittsburgh', 'Portland', 'Raleigh', 'Saint Louis', 'Salt Lake City', 'San Francisco', 'Santa Fe', 'Sault St. Marie', 'Seattle', 'Toronto', 'Vancouver', 'Washington', 'Winnipeg']
 //TODO: build enum/array for destination
 var destinationNames = []string{"Atlanta", "Boston", "Calgary", "Charleston", "Chicago", "Dallas", "Denver", "Duluth", "El_Paso", "Helena", "Houston", "Kansas_City", "Las_Vegas", "Little_Rock", "Los_Angeles", "Miami", "Montreal", "Nashville", "New_Orleans", "New_York", "Oklahoma_City", "Omaha", "Phoenix", "Pittsburgh", "Portland", "Raleigh", "Saint_Louis", "Salt_Lake_City", "San_Francisco", "Santa_Fe", "Sault_St_Marie", "Seattle", "Toronto", "Vancouver", "Washington", "Winnipeg"}
@@ -86,15 +125,35 @@ var listOfDestinationTickets = []DestinationTicket{{Boston, Miami,12},{Calgary,P
 	{Portland, Phoenix, 11},{San_Francisco, Atlanta,17},{Sault_St_Marie, Nashville,8},{Sault_St_Marie,Oklahoma_City, 9},
 	{Seattle, Los_Angeles, 9},{Seattle, New_York, 22},{Toronto, Miami, 10},{Vancouver, Montreal,20 },{Vancouver, Santa_Fe, 13},
 	{Winnipeg, Houston,12},{Winnipeg, Little_Rock,11 }}
+
+//listOfExpandedDestinationTickets is the deck of the expanded variant: the original tickets, and five more
+//it stands in for the deck of the USA 1910 expansion, which adds about 35 tickets that aren't here
+var listOfExpandedDestinationTickets = append(append([]DestinationTicket(nil), listOfDestinationTickets...),
+	DestinationTicket{Boston, Atlanta, 9}, DestinationTicket{Calgary, Nashville, 14}, DestinationTicket{Denver, Miami, 15},
+	DestinationTicket{Portland, Omaha, 12}, DestinationTicket{San_Francisco, Winnipeg, 12})
+
+//listOfHubCitiesDestinationTickets is the deck of the hub cities variant, where every ticket goes to Chicago, Dallas, Los Angeles, Miami, New York or Seattle
+//it is generated, not the published Big Cities deck: every pair of them, and each of them with a smaller city; tickets the original deck also has are worth the same
+var listOfHubCitiesDestinationTickets = []DestinationTicket{{Chicago, Dallas, 6}, {Chicago, Los_Angeles, 16}, {Chicago, Miami, 11},
+	{Chicago, New_York, 5}, {Chicago, Seattle, 15}, {Dallas, Los_Angeles, 10}, {Dallas, Miami, 9}, {Dallas, New_York, 11},
+	{Dallas, Seattle, 16}, {Los_Angeles, Miami, 20}, {Los_Angeles, New_York, 21}, {Los_Angeles, Seattle, 9}, {Miami, New_York, 10},
+	{Miami, Seattle, 23}, {New_York, Seattle, 22}, {Chicago, Boston, 7}, {Chicago, Denver, 8}, {Chicago, Helena, 9},
+	{Dallas, Atlanta, 6}, {Dallas, Denver, 6}, {Dallas, Salt_Lake_City, 9}, {Dallas, Toronto, 10}, {Los_Angeles, Calgary, 12},
+	{Los_Angeles, Houston, 11}, {Los_Angeles, Omaha, 12}, {Miami, Kansas_City, 11}, {Miami, Montreal, 13}, {Miami, Saint_Louis, 9},
+	{New_York, Denver, 12}, {New_York, Houston, 11}, {New_York, Winnipeg, 12}, {Seattle, Duluth, 12}, {Seattle, Oklahoma_City, 14},
+	{Seattle, Santa_Fe, 12}, {Seattle, Toronto, 17}}
 //TODO: build Track array
-var listOfTracks = []Track{{0, Vancouver, Seattle, Other, 1}, {1, Seattle, Portland, Other, 1}, {2, Portland, San_Francisco, Green, 5}, {3, San_Francisco, Los_Angeles, Purple, 3}, {4, Los_Angeles, El_Paso, Black, 6},
-	{5, Los_Angeles, Phoenix, Other, 3}, {6, Phoenix, El_Paso, Other, 3}, {7, Los_Angeles, Las_Vegas, Other, 2}, {8, San_Francisco, Salt_Lake_City, Orange, 5}, {9, Portland, Salt_Lake_City, Blue, 6}, {10, Seattle, Helena, Yellow, 6}, {11, Seattle, Calgary, Other, 4}, {12, Vancouver, Calgary, Other, 3}, {13, Calgary, Winnipeg, White, 6},
-	{14, Calgary, Helena, Other, 4}, {15, Winnipeg, Helena, Blue, 4}, {16, Helena, Salt_Lake_City, Purple, 3}, {17, Salt_Lake_City, Las_Vegas, Orange, 3}, {18, Salt_Lake_City, Denver, Red, 3}, {19, Phoenix, Denver, White, 5}, {20, Phoenix, Santa_Fe, Other, 3}, {21, El_Paso, Santa_Fe, Other, 2}, {22, Santa_Fe, Denver, Other, 2},
-	{23, Helena, Duluth, Orange, 6}, {24, Winnipeg, Duluth, Black, 4}, {25, Winnipeg, Sault_St_Marie, Other, 6}, {26, Duluth, Sault_St_Marie, Other, 3}, {27, Sault_St_Marie, Montreal, Black, 5}, {28, Sault_St_Marie, Toronto, Other, 2}, {29, Montreal, Toronto, Other, 3}, {30, Duluth, Toronto, Purple, 6}, {31, Montreal, Boston, Other, 2}, {32, Boston, New_York, Red, 2},
-	{33, Montreal, New_York, Blue, 3}, {34, New_York, Washington, Black, 2}, {35, Pittsburgh, New_York, Green, 2}, {36, Toronto, Pittsburgh, Other, 2}, {37, Chicago, Pittsburgh, Black, 3}, {38, Toronto, Chicago, White, 4}, {39, Duluth, Chicago, Red, 3},
-	{40, Duluth, Omaha, Other, 2}, {41, Omaha, Chicago, Blue, 4}, {42, Omaha, Kansas_City, Other, 1}, {43, Denver, Omaha, Purple, 4}, {44, Helena, Denver, Green, 4}, {45, Denver, Kansas_City, Orange, 4}, {46, Denver, Oklahoma_City, Red, 4}, {47, Santa_Fe, Oklahoma_City, Blue, 3}, {48, El_Paso, Oklahoma_City, Yellow, 5}, {49, Oklahoma_City, Dallas, Other, 2}, {50, Dallas, Houston, Other, 1}, {51, El_Paso, Houston, Green, 6}, {52, El_Paso, Dallas, Red, 4},
-	{53, Houston, New_Orleans, Other, 2}, {54, Oklahoma_City, Little_Rock, Other, 2}, {55, Little_Rock, Dallas, Other, 2}, {56, Kansas_City, Saint_Louis, Purple, 2}, {57, Chicago, Saint_Louis, Green, 2}, {58, Little_Rock, Saint_Louis, Other, 2}, {59, Saint_Louis, Nashville, Other, 2}, {60, Little_Rock, Nashville, White, 3}, {61, Little_Rock, New_Orleans, Green, 3}, {62, New_Orleans, Atlanta, Yellow, 4}, {63, Atlanta, Charleston, Other, 2}, {64, Charleston, Miami, Purple, 4}, {65, New_Orleans, Miami, Red, 6}, {66, Atlanta, Miami, Blue, 6},
-	{67, Raleigh, Charleston, Other, 2}, {68, Nashville, Raleigh, Other, 2}, {69, Nashville, Raleigh, Black, 3}, {70, Raleigh, Washington, Other, 2}, {71, Washington, Pittsburgh, Other, 2}, {72, Pittsburgh, Raleigh, Other, 2}, {73, Pittsburgh, Saint_Louis, Yellow, 4}, {74, Pittsburgh, Saint_Louis, Green, 5}, {75, Helena, Omaha, Red, 5}, {76, Kansas_City, Oklahoma_City, Other, 2}, {77, Nashville, Atlanta, Other, 1}}
+var listOfTracks = []Track{{0, Vancouver, Seattle, Other, 1, NormalRoute, 0}, {1, Seattle, Portland, Other, 1, NormalRoute, 0}, {2, Portland, San_Francisco, Green, 5, NormalRoute, 0}, {3, San_Francisco, Los_Angeles, Purple, 3, NormalRoute, 0}, {4, Los_Angeles, El_Paso, Black, 6, NormalRoute, 0},
+	{5, Los_Angeles, Phoenix, Other, 3, NormalRoute, 0}, {6, Phoenix, El_Paso, Other, 3, NormalRoute, 0}, {7, Los_Angeles, Las_Vegas, Other, 2, NormalRoute, 0}, {8, San_Francisco, Salt_Lake_City, Orange, 5, NormalRoute, 0}, {9, Portland, Salt_Lake_City, Blue, 6, NormalRoute, 0}, {10, Seattle, Helena, Yellow, 6, NormalRoute, 0}, {11, Seattle, Calgary, Other, 4, NormalRoute, 0}, {12, Vancouver, Calgary, Other, 3, NormalRoute, 0}, {13, Calgary, Winnipeg, White, 6, NormalRoute, 0},
+	{14, Calgary, Helena, Other, 4, NormalRoute, 0}, {15, Winnipeg, Helena, Blue, 4, NormalRoute, 0}, {16, Helena, Salt_Lake_City, Purple, 3, NormalRoute, 0}, {17, Salt_Lake_City, Las_Vegas, Orange, 3, NormalRoute, 0}, {18, Salt_Lake_City, Denver, Red, 3, NormalRoute, 0}, {19, Phoenix, Denver, White, 5, NormalRoute, 0}, {20, Phoenix, Santa_Fe, Other, 3, NormalRoute, 0}, {21, El_Paso, Santa_Fe, Other, 2, NormalRoute, 0}, {22, Santa_Fe, Denver, Other, 2, NormalRoute, 0},
+	{23, Helena, Duluth, Orange, 6, NormalRoute, 0}, {24, Winnipeg, Duluth, Black, 4, NormalRoute, 0}, {25, Winnipeg, Sault_St_Marie, Other, 6, NormalRoute, 0}, {26, Duluth, Sault_St_Marie, Other, 3, NormalRoute, 0}, {27, Sault_St_Marie, Montreal, Black, 5, NormalRoute, 0}, {28, Sault_St_Marie, Toronto, Other, 2, NormalRoute, 0}, {29, Montreal, Toronto, Other, 3, NormalRoute, 0}, {30, Duluth, Toronto, Purple, 6, NormalRoute, 0}, {31, Montreal, Boston, Other, 2, NormalRoute, 0}, {32, Boston, New_York, Red, 2, NormalRoute, 0},
+	{33, Montreal, New_York, Blue, 3, NormalRoute, 0}, {34, New_York, Washington, Black, 2, NormalRoute, 0}, {35, Pittsburgh, New_York, Green, 2, NormalRoute, 0}, {36, Toronto, Pittsburgh, Other, 2, NormalRoute, 0}, {37, Chicago, Pittsburgh, Black, 3, NormalRoute, 0}, {38, Toronto, Chicago, White, 4, NormalRoute, 0}, {39, Duluth, Chicago, Red, 3, NormalRoute, 0},
+	{40, Duluth, Omaha, Other, 2, NormalRoute, 0}, {41, Omaha, Chicago, Blue, 4, NormalRoute, 0}, {42, Omaha, Kansas_City, Other, 1, NormalRoute, 0}, {43, Denver, Omaha, Purple, 4, NormalRoute, 0}, {44, Helena, Denver, Green, 4, NormalRoute, 0}, {45, Denver, Kansas_City, Orange, 4, NormalRoute, 0}, {46, Denver, Oklahoma_City, Red, 4, NormalRoute, 0}, {47, Santa_Fe, Oklahoma_City, Blue, 3, NormalRoute, 0}, {48, El_Paso, Oklahoma_City, Yellow, 5, NormalRoute, 0}, {49, Oklahoma_City, Dallas, Other, 2, NormalRoute, 0}, {50, Dallas, Houston, Other, 1, NormalRoute, 0}, {51, El_Paso, Houston, Green, 6, NormalRoute, 0}, {52, El_Paso, Dallas, Red, 4, NormalRoute, 0},
+	{53, Houston, New_Orleans, Other, 2, NormalRoute, 0}, {54, Oklahoma_City, Little_Rock, Other, 2, NormalRoute, 0}, {55, Little_Rock, Dallas, Other, 2, NormalRoute, 0}, {56, Kansas_City, Saint_Louis, Purple, 2, NormalRoute, 0}, {57, Chicago, Saint_Louis, Green, 2, NormalRoute, 0}, {58, Little_Rock, Saint_Louis, Other, 2, NormalRoute, 0}, {59, Saint_Louis, Nashville, Other, 2, NormalRoute, 0}, {60, Little_Rock, Nashville, White, 3, NormalRoute, 0}, {61, Little_Rock, New_Orleans, Green, 3, NormalRoute, 0}, {62, New_Orleans, Atlanta, Yellow, 4, NormalRoute, 0}, {63, Atlanta, Charleston, Other, 2, NormalRoute, 0}, {64, Charleston, Miami, Purple, 4, NormalRoute, 0}, {65, New_Orleans, Miami, Red, 6, NormalRoute, 0}, {66, Atlanta, Miami, Blue, 6, NormalRoute, 0},
+	{67, Raleigh, Charleston, Other, 2, NormalRoute, 0}, {68, Nashville, Raleigh, Other, 2, NormalRoute, 0}, {69, Nashville, Raleigh, Black, 3, NormalRoute, 0}, {70, Raleigh, Washington, Other, 2, NormalRoute, 0}, {71, Washington, Pittsburgh, Other, 2, NormalRoute, 0}, {72, Pittsburgh, Raleigh, Other, 2, NormalRoute, 0}, {73, Pittsburgh, Saint_Louis, Yellow, 4, NormalRoute, 0}, {74, Pittsburgh, Saint_Louis, Green, 5, NormalRoute, 0}, {75, Helena, Omaha, Red, 5, NormalRoute, 0}, {76, Kansas_City, Oklahoma_City, Other, 2, NormalRoute, 0}, {77, Nashville, Atlanta, Other, 1, NormalRoute, 0}}
+
+//pairs of parallel tracks in listOfTracks that form a double route
+var listOfDoubleRoutes = [][]int{{68, 69}, {73, 74}}
 
 var mapPositions = map[string]string{
 	"Vancouver": "0,0",
@@ -0,0 +1,79 @@
+package main
+
+import "strconv"
+
+//GameEndReason says why a game ended
+type GameEndReason int
+
+const (
+	EndedAfterFinalRound GameEndReason = iota //a player ran low on trains, and everybody had one more turn
+	EndedStalled                              //every player in turn went without progress, like asking for cards when there are none left
+	EndedAllDisqualified                      //the illegal move policy disqualified every player
+	EndedAborted                              //the illegal move policy aborted the game
+)
+
+var gameEndReasonNames = []string{"final round played", "stalled", "all players disqualified", "aborted"}
+
+func (r GameEndReason) String() string {
+	if r < 0 || int(r) >= len(gameEndReasonNames) {
+		return "unknown reason " + strconv.Itoa(int(r))
+	}
+	return gameEndReasonNames[r]
+}
+
+//TicketResult is what a destination ticket was worth at the end of a game
+type TicketResult struct {
+	Ticket    DestinationTicket
+	Completed bool
+	Points    int //the ticket's points if it was completed, and minus its points if it wasn't
+}
+
+//PlayerResult is how a player did in a game
+type PlayerResult struct {
+	Score             int //RoutePoints + TicketPoints + StationPoints + LongestPathBonus + GlobetrotterBonus
+	RoutePoints       int
+	TicketPoints      int
+	Tickets           []TicketResult
+	StationPoints     int   //the points for the stations the player didn't build
+	BorrowedTracks    []int //the tracks of other players the player's stations let it use for its tickets
+	LongestPath       int   //the length of the player's longest continuous path
+	LongestPathTracks []int //the tracks of that path, in order from one end to the other
+	LongestPathBonus  int
+	GlobetrotterBonus int //for completing the most destination tickets, in the expanded and all tickets variants
+	TrainsLeft        int
+	Disqualified      bool
+	Timing            PlayerTiming //how long the player took, if the players had time limits
+}
+
+//TicketsCompleted is how many of the player's destination tickets were completed
+func (p PlayerResult) TicketsCompleted() int {
+	completed := 0
+	for _, ticket := range p.Tickets {
+		if ticket.Completed {
+			completed++
+		}
+	}
+	return completed
+}
+
+//GameResult is how a game ended
+type GameResult struct {
+	Seed           int64
+	Winners        []int          //more than one if they are still tied after the tie breakers, and empty if the game was aborted or every player was disqualified
+	Players        []PlayerResult //empty if the game was aborted: aborted games are never scored
+	NumTurns       int
+	EndReason      GameEndReason
+	RuleViolations []RuleViolation
+}
+
+//Scores returns the final score of every player, or nil if the game was never scored
+func (r GameResult) Scores() []int {
+	if r.Players == nil {
+		return nil
+	}
+	scores := make([]int, len(r.Players))
+	for i, player := range r.Players {
+		scores[i] = player.Score
+	}
+	return scores
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+//HumanConsolePlayer lets a person play from a terminal: it prints what the player may know, and asks for every decision until the answer is legal
+type HumanConsolePlayer struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	myNumber  int
+	trackList []Track
+	constants GameConstants
+	view      PlayerView //the view of the last informStatus, which askMove and askTrackLay are answered from
+
+	inputClosed bool //once the input is closed, every question gets an illegal answer, and the engine's illegal move policy takes over
+}
+
+//newHumanConsolePlayer makes a player that reads its answers from in; players sharing an input must share its scanner, which reads ahead
+func newHumanConsolePlayer(in *bufio.Scanner, out io.Writer) *HumanConsolePlayer {
+	return &HumanConsolePlayer{in: in, out: out}
+}
+
+func (h *HumanConsolePlayer) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) {
+	h.myNumber = myNumber
+	h.trackList = trackList
+	h.constants = constants
+	fmt.Fprintf(h.out, "You are player %d of %d.\n", myNumber, constants.NumPlayers)
+}
+
+//describeTrack names a track for people, with its number
+func describeTrack(destinationNames []string, trackList []Track, track int) string {
+	t := trackList[track]
+	switch t.kind {
+	case TunnelRoute:
+		return fmt.Sprintf("%d %s - %s (%s, %d, tunnel)", track, destinationNames[t.d1], destinationNames[t.d2], stringColors[t.c], t.length)
+	case FerryRoute:
+		return fmt.Sprintf("%d %s - %s (%s, %d, ferry taking %d rainbows)", track, destinationNames[t.d1], destinationNames[t.d2], stringColors[t.c], t.length, t.locomotives)
+	}
+	return fmt.Sprintf("%d %s - %s (%s, %d)", track, destinationNames[t.d1], destinationNames[t.d2], stringColors[t.c], t.length)
+}
+
+func describeTicket(destinationNames []string, ticket DestinationTicket) string {
+	return fmt.Sprintf("%s - %s (%d points)", destinationNames[ticket.d1], destinationNames[ticket.d2], ticket.points)
+}
+
+func (h *HumanConsolePlayer) playerName(player int) string {
+	if player == h.myNumber {
+		return "You"
+	}
+	return "Player " + strconv.Itoa(player)
+}
+
+func (h *HumanConsolePlayer) informCardPickup(player int, color GameColor) {
+	if player == h.myNumber {
+		return
+	}
+	if color == Other {
+		fmt.Fprintf(h.out, "%s drew a card from the deck.\n", h.playerName(player))
+	} else {
+		fmt.Fprintf(h.out, "%s picked up a face up %s card.\n", h.playerName(player), stringColors[color])
+	}
+}
+
+func (h *HumanConsolePlayer) informTrackLay(player int, track int) {
+	fmt.Fprintf(h.out, "%s claimed %s.\n", h.playerName(player), describeTrack(h.constants.DestinationNames, h.trackList, track))
+}
+
+func (h *HumanConsolePlayer) informDestinationTicketPickup(player int) {
+	if player != h.myNumber {
+		fmt.Fprintf(h.out, "%s kept a destination ticket.\n", h.playerName(player))
+	}
+}
+
+func (h *HumanConsolePlayer) informFinalRound(player int) {
+	fmt.Fprintf(h.out, "%s dropped to few enough trains to start the final round: everybody gets one more turn.\n", h.playerName(player))
+}
+
+func (h *HumanConsolePlayer) informGameResumed(numTrains []int) {
+	fmt.Fprintln(h.out, "The game was resumed from a snapshot.")
+}
+
+//ticketConnected says whether the player's tracks connect the destinations of a ticket
+func ticketConnected(trackList []Track, trackStatus []int, player int, ticket DestinationTicket) bool {
+	seen := map[Destination]bool{ticket.d1: true}
+	toVisit := []Destination{ticket.d1}
+	for len(toVisit) > 0 {
+		d := toVisit[0]
+		toVisit = toVisit[1:]
+		if d == ticket.d2 {
+			return true
+		}
+		for i, t := range trackList {
+			if trackStatus[i] != player {
+				continue
+			}
+			for _, next := range []Destination{t.d1, t.d2} {
+				if (t.d1 == d || t.d2 == d) && !seen[next] {
+					seen[next] = true
+					toVisit = append(toVisit, next)
+				}
+			}
+		}
+	}
+	return false
+}
+
+func describeCards(cards []int) string {
+	described := make([]string, 0)
+	for c, howMany := range cards {
+		if howMany > 0 {
+			described = append(described, fmt.Sprintf("%s %d", stringColors[c], howMany))
+		}
+	}
+	if len(described) == 0 {
+		return "none"
+	}
+	return strings.Join(described, ", ")
+}
+
+func describePayment(payment Payment) string {
+	if payment.NumColored == 0 {
+		return fmt.Sprintf("rainbow %d", payment.NumRainbows)
+	}
+	if payment.NumRainbows == 0 {
+		return fmt.Sprintf("%s %d", stringColors[payment.Color], payment.NumColored)
+	}
+	return fmt.Sprintf("%s %d + rainbow %d", stringColors[payment.Color], payment.NumColored, payment.NumRainbows)
+}
+
+func (h *HumanConsolePlayer) informStatus(view PlayerView) {
+	h.view = view
+
+	fmt.Fprintf(h.out, "\n=== Your turn, player %d ===\n", h.myNumber)
+	for p := 0; p < view.NumPlayers(); p++ {
+		fmt.Fprintf(h.out, "%s: %d trains, %d train cards, %d destination tickets\n", h.playerName(p), view.NumTrains(p), view.NumTrainCards(p), view.NumDestinationTickets(p))
+	}
+	fmt.Fprintf(h.out, "Deck: %d cards, discard pile: %d cards, destination tickets: %d\n", view.DeckSize(), view.DiscardPileSize(), view.DestinationTicketPileSize())
+	fmt.Fprintf(h.out, "Face up: %s\n", describeCards(view.FaceUpTrainCards()))
+	fmt.Fprintf(h.out, "Your hand: %s\n", describeCards(view.TrainCards()))
+
+	fmt.Fprintln(h.out, "Your tickets:")
+	trackStatus := view.TrackStatus()
+	for _, ticket := range view.DestinationTickets() {
+		status := "not connected yet"
+		if ticketConnected(h.trackList, trackStatus, h.myNumber, ticket) {
+			status = "connected"
+		}
+		fmt.Fprintf(h.out, "  %s: %s\n", describeTicket(h.constants.DestinationNames, ticket), status)
+	}
+
+	fmt.Fprintln(h.out, "Tracks you can claim:")
+	claimable := claimableTracks(view)
+	if len(claimable) == 0 {
+		fmt.Fprintln(h.out, "  none")
+	}
+	for _, option := range claimable {
+		payments := make([]string, len(option.Payments))
+		for i, payment := range option.Payments {
+			payments[i] = describePayment(payment)
+		}
+		fmt.Fprintf(h.out, "  %s: pay with %s\n", describeTrack(h.constants.DestinationNames, h.trackList, option.Track), strings.Join(payments, " or "))
+	}
+
+	if h.constants.NumStations > 0 {
+		built := make([]string, 0)
+		for city, owner := range view.Stations() {
+			if owner != -1 {
+				built = append(built, fmt.Sprintf("%s (%s)", h.constants.DestinationNames[city], h.playerName(owner)))
+			}
+		}
+		if len(built) > 0 {
+			fmt.Fprintf(h.out, "Stations: %s\n", strings.Join(built, ", "))
+		}
+		fmt.Fprintf(h.out, "You have %d stations left", view.StationsLeft(h.myNumber))
+		if view.StationsLeft(h.myNumber) > 0 {
+			fmt.Fprintf(h.out, ", and the next one takes %d cards", view.stationCost())
+		}
+		fmt.Fprintln(h.out, ".")
+	}
+}
+
+//prompt asks a question and returns the answer; ok is false once the input is closed
+func (h *HumanConsolePlayer) prompt(question string) (answer string, ok bool) {
+	if h.inputClosed {
+		return "", false
+	}
+	fmt.Fprint(h.out, question)
+	if !h.in.Scan() {
+		h.inputClosed = true
+		fmt.Fprintln(h.out, "\nThe input was closed, so you can't make any more moves.")
+		return "", false
+	}
+	return strings.TrimSpace(h.in.Text()), true
+}
+
+func (h *HumanConsolePlayer) askMove() int {
+	legal := LegalMoves(h.view).Moves
+	question, answers := "Draw train [c]ards, claim a [t]rack or draw [d]estination tickets? ", "c, t or d"
+	if h.constants.NumStations > 0 {
+		question, answers = "Draw train [c]ards, claim a [t]rack, draw [d]estination tickets or build a [s]tation? ", "c, t, d or s"
+	}
+	for {
+		answer, ok := h.prompt(question)
+		if !ok {
+			return -1
+		}
+		move, known := map[string]int{"c": 0, "cards": 0, "0": 0, "t": 1, "track": 1, "1": 1, "d": 2, "destination": 2, "2": 2, "s": 3, "station": 3, "3": 3}[strings.ToLower(answer)]
+		if !known {
+			fmt.Fprintf(h.out, "Please answer %s.\n", answers)
+			continue
+		}
+		if !itemExists(legal, move) {
+			rule, _ := moveRuleBroken(h.view, move)
+			fmt.Fprintf(h.out, "You can't: %v.\n", rule)
+			continue
+		}
+		return move
+	}
+}
+
+//parseColor reads a color by name, or Other for the deck
+func (h *HumanConsolePlayer) parseColor(answer string) (GameColor, bool) {
+	answer = strings.ToLower(answer)
+	if answer == "deck" || answer == "d" {
+		return Other, true
+	}
+	for c := 0; c < h.constants.NumGameColors; c++ {
+		if stringColors[c] == answer {
+			return GameColor(c), true
+		}
+	}
+	return 0, false
+}
+
+func (h *HumanConsolePlayer) askPickup(howManyLeft int, view PlayerView) GameColor {
+	fmt.Fprintf(h.out, "Face up: %s\n", describeCards(view.FaceUpTrainCards()))
+	for {
+		answer, ok := h.prompt(fmt.Sprintf("Pick up card %d of 2: a face up color, or [d]eck? ", 3-howManyLeft))
+		if !ok {
+			return -1
+		}
+		color, known := h.parseColor(answer)
+		if !known {
+			fmt.Fprintln(h.out, "Please answer with a color, like red, or d for the deck.")
+			continue
+		}
+		if rule, broken := pickupRuleBroken(view, color, howManyLeft); broken {
+			fmt.Fprintf(h.out, "You can't: %v.\n", rule)
+			continue
+		}
+		return color
+	}
+}
+
+func (h *HumanConsolePlayer) askTrackLay() (int, Payment) {
+	for {
+		answer, ok := h.prompt("Which track (its number)? ")
+		if !ok {
+			return -1, Payment{Color: Other}
+		}
+		track, err := strconv.Atoi(answer)
+		if err != nil {
+			fmt.Fprintln(h.out, "Please answer with the number of a track from the list.")
+			continue
+		}
+		if track < 0 || track >= len(h.trackList) {
+			fmt.Fprintf(h.out, "You can't: %v.\n", RuleInvalidTrack)
+			continue
+		}
+		payments := trackPayments(h.view, track)
+		if len(payments) == 0 {
+			fmt.Fprintln(h.out, "You can't claim that track: pick one from the list.")
+			continue
+		}
+		if len(payments) == 1 {
+			return track, payments[0]
+		}
+
+		for i, payment := range payments {
+			fmt.Fprintf(h.out, "  %d) %s\n", i+1, describePayment(payment))
+		}
+		answer, ok = h.prompt("Pay how? ")
+		if !ok {
+			return -1, Payment{Color: Other}
+		}
+		choice, err := strconv.Atoi(answer)
+		if err != nil || choice < 1 || choice > len(payments) {
+			fmt.Fprintln(h.out, "Please answer with the number of a payment from the list.")
+			continue
+		}
+		return track, payments[choice-1]
+	}
+}
+
+func (h *HumanConsolePlayer) askStation() (Destination, Payment) {
+	for {
+		answer, ok := h.prompt("Build a station in which city? ")
+		if !ok {
+			return -1, Payment{Color: Other}
+		}
+		city := Destination(-1)
+		for d, name := range h.constants.DestinationNames {
+			if strings.EqualFold(name, strings.ReplaceAll(answer, " ", "_")) {
+				city = Destination(d)
+			}
+		}
+		if city == -1 {
+			fmt.Fprintln(h.out, "Please answer with the name of a city on the board.")
+			continue
+		}
+		payments := stationPayments(h.view)
+		if len(payments) == 0 {
+			//	the move was legal, so this can't happen
+			return -1, Payment{Color: Other}
+		}
+		if rule, broken := stationRuleBroken(h.view, city, payments[0]); broken {
+			fmt.Fprintf(h.out, "You can't: %v.\n", rule)
+			continue
+		}
+		if len(payments) == 1 {
+			return city, payments[0]
+		}
+
+		for i, payment := range payments {
+			fmt.Fprintf(h.out, "  %d) %s\n", i+1, describePayment(payment))
+		}
+		answer, ok = h.prompt("Pay how? ")
+		if !ok {
+			return -1, Payment{Color: Other}
+		}
+		choice, err := strconv.Atoi(answer)
+		if err != nil || choice < 1 || choice > len(payments) {
+			fmt.Fprintln(h.out, "Please answer with the number of a payment from the list.")
+			continue
+		}
+		return city, payments[choice-1]
+	}
+}
+
+func (h *HumanConsolePlayer) askTunnelPayment(track int, drawn []GameColor, extra Payment) bool {
+	names := make([]string, len(drawn))
+	for i, c := range drawn {
+		names[i] = stringColors[c]
+	}
+	fmt.Fprintf(h.out, "The cards turned over for %s are %s.\n", describeTrack(h.constants.DestinationNames, h.trackList, track), strings.Join(names, ", "))
+	for {
+		answer, ok := h.prompt(fmt.Sprintf("Pay %s more, or give up the claim? [p]ay or [g]ive up: ", describePayment(extra)))
+		if !ok {
+			return false
+		}
+		switch strings.ToLower(answer) {
+		case "p", "pay":
+			return true
+		case "g", "give up":
+			return false
+		}
+		fmt.Fprintln(h.out, "Please answer p or g.")
+	}
+}
+
+func (h *HumanConsolePlayer) giveTrainCard(color GameColor) {
+	fmt.Fprintf(h.out, "You got a %s card.\n", stringColors[color])
+}
+
+func (h *HumanConsolePlayer) giveDestinationTicket(ticket DestinationTicket) {
+	fmt.Fprintf(h.out, "You kept the ticket %s.\n", describeTicket(h.constants.DestinationNames, ticket))
+}
+
+func (h *HumanConsolePlayer) offerDestinationTickets(tickets []DestinationTicket, minKept int) []int {
+	fmt.Fprintln(h.out, "Destination tickets on offer:")
+	for i, ticket := range tickets {
+		fmt.Fprintf(h.out, "  %d) %s\n", i+1, describeTicket(h.constants.DestinationNames, ticket))
+	}
+	for {
+		answer, ok := h.prompt(fmt.Sprintf("Keep which, at least %d (like 1 3)? ", minKept))
+		if !ok {
+			return nil
+		}
+		kept := make([]int, 0)
+		for _, field := range strings.Fields(strings.ReplaceAll(answer, ",", " ")) {
+			choice, err := strconv.Atoi(field)
+			if err != nil {
+				choice = 0
+			}
+			kept = append(kept, choice-1)
+		}
+		if rule, broken := ticketChoiceRuleBroken(kept, len(tickets), minKept); broken {
+			fmt.Fprintf(h.out, "You can't: %v. Please answer with different numbers from 1 to %d.\n", rule, len(tickets))
+			continue
+		}
+		return kept
+	}
+}
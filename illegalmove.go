@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+//Rule identifies which rule of the game an illegal move broke
+type Rule int
+
+const (
+	RuleInvalidMoveChoice Rule = iota
+	RuleMissingFaceUpColor
+	RuleRainbowOnSecondPickup
+	RuleNoTrainCardsLeft
+	RuleInvalidTrack
+	RuleTrackOccupied
+	RuleClosedDoubleRoute
+	RuleRainbowTrackColor
+	RuleWrongTrackColor
+	RuleNotEnoughTrains
+	RuleNotEnoughTrainCards
+	RuleNoDestinationTicketsLeft
+	RuleTooFewDestinationTickets
+	RuleInvalidDestinationTicketIndex
+	RuleNoClaimableTrack
+	RuleNotEnoughLocomotives
+	RuleNoBuildableStation
+	RuleNoStationsLeft
+	RuleInvalidCity
+	RuleCityHasStation
+	RuleInvalidStationColor
+	RuleNotEnoughStationCards
+	RuleWrongPaymentSize
+)
+
+var ruleDescriptions = []string{
+	"the move choice must be 0 (pick up cards), 1 (lay a track), 2 (pick up destination tickets) or 3 (build a station)",
+	"a face up card can only be picked if a card of that color is face up",
+	"a face up rainbow cannot be picked as the second card",
+	"a card cannot be drawn when the deck and the discard pile are empty",
+	"the track does not exist",
+	"a track that is already occupied cannot be claimed",
+	"this half of a double route is closed to the player",
+	"rainbow cannot be the chosen color: pick any other color to pay with rainbows only",
+	"the chosen color does not match the color of the track",
+	"the player does not have enough trains for the track",
+	"the player does not have enough train cards of the chosen color and rainbows for the track",
+	"destination tickets cannot be picked up when the pile is empty",
+	"the player kept fewer destination tickets than required",
+	"the player picked a destination ticket that was not offered, or picked one twice",
+	"the player cannot lay track when there is no track they can claim",
+	"the player does not have enough rainbows for the locomotives the ferry takes",
+	"the player cannot build a station when there is no city they can build one in",
+	"the player has no stations left to build",
+	"the city does not exist",
+	"a city can only have one station",
+	"the chosen color must be a card color other than rainbow: pick any other color to pay with rainbows only",
+	"the player does not have enough train cards of the chosen color and rainbows for the station",
+	"the cards paid must add up to the cost of the track or the station",
+}
+
+func (r Rule) String() string {
+	if r < 0 || int(r) >= len(ruleDescriptions) {
+		return "unknown rule " + strconv.Itoa(int(r))
+	}
+	return ruleDescriptions[r]
+}
+
+//IllegalMovePolicy decides what the engine does when a player breaks a rule
+type IllegalMovePolicy int
+
+const (
+	RetryIllegalMove         IllegalMovePolicy = iota //ask the player again, and forfeit the turn after NUMILLEGALMOVERETRIES failed retries
+	ForfeitTurnOnIllegalMove                          //the player loses the rest of the turn
+	DisqualifyOnIllegalMove                           //the player takes no more turns and cannot win
+	AbortGameOnIllegalMove                            //the game stops immediately without a winner
+)
+
+var illegalMovePolicyNames = []string{"retry", "forfeit", "disqualify", "abort"}
+
+func (p IllegalMovePolicy) String() string {
+	if p < 0 || int(p) >= len(illegalMovePolicyNames) {
+		return "unknown policy " + strconv.Itoa(int(p))
+	}
+	return illegalMovePolicyNames[p]
+}
+
+func parseIllegalMovePolicy(name string) (IllegalMovePolicy, error) {
+	for i, policyName := range illegalMovePolicyNames {
+		if policyName == name {
+			return IllegalMovePolicy(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown illegal move policy %q, expected one of %v", name, illegalMovePolicyNames)
+}
+
+//IllegalMoveError describes a move the engine refused to play
+type IllegalMoveError struct {
+	Player int
+	Move   int    //the move being played: 0 is pick up cards, 1 is place Tracks, 2 is pick destination ticket, 3 is build a station
+	Rule   Rule   //the rule that was broken
+	Detail string //what exactly the player asked for
+}
+
+func (err *IllegalMoveError) Error() string {
+	return "player " + strconv.Itoa(err.Player) + " made an illegal move (" + moveName(err.Move) + ", " + err.Detail + "): " + err.Rule.String()
+}
+
+var moveNames = map[int]string{0: "pick up cards", 1: "lay track", 2: "pick up destination tickets", 3: "build station"}
+
+func moveName(move int) string {
+	if name, ok := moveNames[move]; ok {
+		return name
+	}
+	return "move " + strconv.Itoa(move)
+}
+
+//RuleViolation records an illegal move and which policy the engine applied to it
+type RuleViolation struct {
+	Err           *IllegalMoveError
+	PolicyApplied IllegalMovePolicy
+}
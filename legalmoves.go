@@ -0,0 +1,304 @@
+package main
+
+//Payment is the cards a track or a station is paid with: NumColored cards of Color, and NumRainbows rainbows
+//the player chooses the split, so it can keep cards of the color by paying with more rainbows than it must
+type Payment struct {
+	Color       GameColor //the color chosen in askTrackLay or askStation
+	NumColored  int
+	NumRainbows int
+}
+
+//TrackOption is a track that can be claimed, with every way it can be paid for
+type TrackOption struct {
+	Track    int
+	Payments []Payment //every color and every split between it and rainbows, which askTrackLay answers with
+}
+
+//LegalMoveSet is everything a player may do on its turn
+type LegalMoveSet struct {
+	Moves                     []int         //the legal answers to askMove
+	Pickups                   []GameColor   //the legal answers to the first askPickup of a turn: the face up colors, and Other for the deck
+	SecondPickups             []GameColor   //the legal answers to the second askPickup, if the face up cards were still these: no rainbow; the second askPickup comes with a view of the cards after the first
+	Tracks                    []TrackOption //the legal answers to askTrackLay
+	CanDrawDestinationTickets bool
+	Stations                  []Destination //the cities a station can be built in, under the Europe rules
+	StationPayments           []Payment     //every color the next station can be paid with, and what each of them costs
+}
+
+//LegalMoves lists every legal move of the player whose view it is
+//the engine checks the players' decisions with the same rules, so a move from this list is never refused
+func LegalMoves(view PlayerView) LegalMoveSet {
+	moves := LegalMoveSet{
+		Pickups:                   legalPickups(view, 2),
+		SecondPickups:             legalPickups(view, 1),
+		Tracks:                    claimableTracks(view),
+		CanDrawDestinationTickets: view.destinationTicketPileSize > 0,
+		Stations:                  buildableStations(view),
+		StationPayments:           stationPayments(view),
+	}
+	if len(moves.Stations) == 0 {
+		moves.StationPayments = []Payment{}
+	}
+	for move := 0; move <= 3; move++ {
+		if _, broken := moveRuleBroken(view, move); !broken {
+			moves.Moves = append(moves.Moves, move)
+		}
+	}
+	return moves
+}
+
+//legalPickups lists the legal answers to askPickup, when the player has howManyLeft cards left to pick up this turn
+func legalPickups(view PlayerView, howManyLeft int) []GameColor {
+	pickups := make([]GameColor, 0)
+	for c := range view.faceUpTrainCards {
+		if _, broken := pickupRuleBroken(view, GameColor(c), howManyLeft); !broken {
+			pickups = append(pickups, GameColor(c))
+		}
+	}
+	if _, broken := pickupRuleBroken(view, Other, howManyLeft); !broken {
+		pickups = append(pickups, Other)
+	}
+	return pickups
+}
+
+//claimableTracks lists every track the player can claim right now
+func claimableTracks(view PlayerView) []TrackOption {
+	options := make([]TrackOption, 0)
+	for track := range view.tracks {
+		if payments := trackPayments(view, track); len(payments) > 0 {
+			options = append(options, TrackOption{Track: track, Payments: payments})
+		}
+	}
+	return options
+}
+
+//trackPayments lists every way the player can pay for a track, in order of color; it is empty if the track can't be claimed
+//the first payment of each color is the one paymentFor picks, and the others spend one more rainbow each
+func trackPayments(view PlayerView, track int) []Payment {
+	colors := make([]GameColor, 0)
+	for c := range view.trainCards {
+		if _, broken := trackColorRuleBroken(view, track, GameColor(c)); !broken {
+			colors = append(colors, GameColor(c))
+		}
+	}
+	return paymentSplits(view.trainCards, colors, view.tracks[track].length, view.tracks[track].locomotives)
+}
+
+//paymentSplits lists every way to pay cost cards out of a hand with one of the colors, at least minRainbows of them rainbows
+//paying with rainbows only is the same whichever color is chosen, so it is only listed once, with the first color that allows it
+func paymentSplits(trainCards []int, colors []GameColor, cost, minRainbows int) []Payment {
+	payments := make([]Payment, 0)
+	listedRainbowsOnly := false
+	for _, c := range colors {
+		for payment := payWith(trainCards, c, cost, minRainbows); payment.NumRainbows <= trainCards[Rainbow]; payment.NumColored, payment.NumRainbows = payment.NumColored-1, payment.NumRainbows+1 {
+			if payment.NumColored == 0 {
+				if !listedRainbowsOnly {
+					payments = append(payments, payment)
+				}
+				listedRainbowsOnly = true
+				break
+			}
+			payments = append(payments, payment)
+		}
+	}
+	return payments
+}
+
+//paymentFor works out the cards spent on a track for the chosen color: as many cards of that color as are needed, then rainbows
+//a ferry takes its locomotives as rainbows first
+func paymentFor(view PlayerView, track int, whichColor GameColor) Payment {
+	return payWith(view.trainCards, whichColor, view.tracks[track].length, view.tracks[track].locomotives)
+}
+
+//defaultTrackPayment is how a player that only chooses a color pays for a track, as paymentFor does
+//if the color can't pay for the track at all, it pays no cards, and the engine refuses the claim for the color
+func defaultTrackPayment(view PlayerView, track int, whichColor GameColor) Payment {
+	if _, broken := trackColorRuleBroken(view, track, whichColor); broken {
+		return Payment{Color: whichColor}
+	}
+	return paymentFor(view, track, whichColor)
+}
+
+//defaultStationPayment is how a player that only chooses a color pays for its next station, like defaultTrackPayment
+func defaultStationPayment(view PlayerView, whichColor GameColor) Payment {
+	if _, broken := stationColorRuleBroken(view, whichColor); broken {
+		return Payment{Color: whichColor}
+	}
+	return payWith(view.trainCards, whichColor, view.stationCost(), 0)
+}
+
+//payWith pays cost cards out of a hand: minRainbows rainbows, then as many cards of the chosen color as are needed, then rainbows again
+func payWith(trainCards []int, whichColor GameColor, cost, minRainbows int) Payment {
+	numColored := min(trainCards[whichColor], cost-minRainbows)
+	return Payment{Color: whichColor, NumColored: numColored, NumRainbows: cost - numColored}
+}
+
+//moveRuleBroken says which rule, if any, forbids the player from choosing a move
+func moveRuleBroken(view PlayerView, whichMove int) (Rule, bool) {
+	if whichMove < 0 || whichMove > 3 {
+		return RuleInvalidMoveChoice, true
+	}
+	if whichMove == 0 && len(legalPickups(view, 2)) == 0 {
+		return RuleNoTrainCardsLeft, true
+	}
+	if whichMove == 1 && len(claimableTracks(view)) == 0 {
+		return RuleNoClaimableTrack, true
+	}
+	if whichMove == 2 && view.destinationTicketPileSize == 0 {
+		return RuleNoDestinationTicketsLeft, true
+	}
+	if whichMove == 3 && len(buildableStations(view)) == 0 {
+		return RuleNoBuildableStation, true
+	}
+	return 0, false
+}
+
+//pickupRuleBroken says which rule, if any, forbids the player from picking up a card of a color, or from the deck if the color is Other
+func pickupRuleBroken(view PlayerView, whichColor GameColor, howManyLeft int) (Rule, bool) {
+	if whichColor == Other {
+		//	asking for a random card from the deck
+		if view.NumTrainCardsLeftToDraw() == 0 {
+			return RuleNoTrainCardsLeft, true
+		}
+		return 0, false
+	}
+	if whichColor < 0 || int(whichColor) >= len(view.faceUpTrainCards) {
+		return RuleMissingFaceUpColor, true
+	}
+	if whichColor == Rainbow && howManyLeft < 2 {
+		return RuleRainbowOnSecondPickup, true
+	}
+	if view.faceUpTrainCards[whichColor] <= 0 {
+		return RuleMissingFaceUpColor, true
+	}
+	return 0, false
+}
+
+//trackLayRuleBroken says which rule, if any, forbids the player from claiming a track with a payment
+func trackLayRuleBroken(view PlayerView, whichTrack int, payment Payment) (Rule, bool) {
+	if rule, broken := trackColorRuleBroken(view, whichTrack, payment.Color); broken {
+		return rule, true
+	}
+	track := view.tracks[whichTrack]
+	if rule, broken := paymentRuleBroken(view, payment, track.length); broken {
+		return rule, true
+	}
+	if payment.NumRainbows < track.locomotives {
+		return RuleNotEnoughLocomotives, true
+	}
+	return 0, false
+}
+
+//paymentRuleBroken says which rule, if any, forbids the player from paying cost cards with a payment
+func paymentRuleBroken(view PlayerView, payment Payment, cost int) (Rule, bool) {
+	if payment.NumColored < 0 || payment.NumRainbows < 0 || payment.NumColored+payment.NumRainbows != cost {
+		return RuleWrongPaymentSize, true
+	}
+	if payment.NumColored > view.trainCards[payment.Color] || payment.NumRainbows > view.trainCards[Rainbow] {
+		return RuleNotEnoughTrainCards, true
+	}
+	return 0, false
+}
+
+//trackColorRuleBroken says which rule, if any, forbids the player from claiming a track with a color, however the payment is split
+func trackColorRuleBroken(view PlayerView, whichTrack int, whichColor GameColor) (Rule, bool) {
+	if whichTrack < 0 || whichTrack >= len(view.tracks) {
+		return RuleInvalidTrack, true
+	}
+	if whichColor < 0 || int(whichColor) >= len(view.trainCards) {
+		return RuleWrongTrackColor, true
+	}
+	if view.trackStatus[whichTrack] == CLOSEDTRACK {
+		return RuleClosedDoubleRoute, true
+	}
+	if view.trackStatus[whichTrack] != -1 {
+		return RuleTrackOccupied, true
+	}
+	if whichColor == Rainbow {
+		return RuleRainbowTrackColor, true
+	}
+	track := view.tracks[whichTrack]
+	if track.c != whichColor && track.c != Other {
+		return RuleWrongTrackColor, true
+	}
+	if track.length > view.numTrains[view.player] {
+		return RuleNotEnoughTrains, true
+	}
+	if track.length > view.trainCards[whichColor]+view.trainCards[Rainbow] {
+		return RuleNotEnoughTrainCards, true
+	}
+	if track.locomotives > view.trainCards[Rainbow] {
+		return RuleNotEnoughLocomotives, true
+	}
+	return 0, false
+}
+
+//buildableStations lists the cities the player can build a station in right now
+func buildableStations(view PlayerView) []Destination {
+	cities := make([]Destination, 0)
+	if len(stationPayments(view)) == 0 {
+		return cities
+	}
+	for city, owner := range view.stations {
+		if owner == -1 {
+			cities = append(cities, Destination(city))
+		}
+	}
+	return cities
+}
+
+//stationPayments lists every way the player can pay for its next station, like trackPayments
+func stationPayments(view PlayerView) []Payment {
+	if view.StationsLeft(view.player) == 0 {
+		return make([]Payment, 0)
+	}
+	colors := make([]GameColor, 0)
+	for c := range view.trainCards {
+		if _, broken := stationColorRuleBroken(view, GameColor(c)); !broken {
+			colors = append(colors, GameColor(c))
+		}
+	}
+	return paymentSplits(view.trainCards, colors, view.stationCost(), 0)
+}
+
+//stationRuleBroken says which rule, if any, forbids the player from building a station in a city with a payment
+func stationRuleBroken(view PlayerView, city Destination, payment Payment) (Rule, bool) {
+	if view.StationsLeft(view.player) == 0 {
+		return RuleNoStationsLeft, true
+	}
+	if city < 0 || int(city) >= len(view.stations) {
+		return RuleInvalidCity, true
+	}
+	if view.stations[city] != -1 {
+		return RuleCityHasStation, true
+	}
+	if rule, broken := stationColorRuleBroken(view, payment.Color); broken {
+		return rule, true
+	}
+	return paymentRuleBroken(view, payment, view.stationCost())
+}
+
+func stationColorRuleBroken(view PlayerView, whichColor GameColor) (Rule, bool) {
+	if whichColor < 0 || int(whichColor) >= len(view.trainCards) || whichColor == Rainbow {
+		return RuleInvalidStationColor, true
+	}
+	if view.stationCost() > view.trainCards[whichColor]+view.trainCards[Rainbow] {
+		return RuleNotEnoughStationCards, true
+	}
+	return 0, false
+}
+
+//ticketChoiceRuleBroken says which rule, if any, forbids the player from keeping these of the numOffered destination tickets on offer
+func ticketChoiceRuleBroken(kept []int, numOffered, minKept int) (Rule, bool) {
+	if len(kept) < minKept {
+		return RuleTooFewDestinationTickets, true
+	}
+	alreadySeen := make([]int, 0) //used to prevent skirting the rules by listing duplicates
+	for _, accepted := range kept {
+		if accepted < 0 || accepted >= numOffered || itemExists(alreadySeen, accepted) {
+			return RuleInvalidDestinationTicketIndex, true
+		}
+		alreadySeen = append(alreadySeen, accepted)
+	}
+	return 0, false
+}
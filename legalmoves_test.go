@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+//the grey track from Los Angeles to Phoenix, 3 long, with 3 red cards and 2 rainbows in hand
+func splitTestView() (PlayerView, int) {
+	trackStatus := make([]int, len(listOfTracks))
+	for i := range trackStatus {
+		trackStatus[i] = -1
+	}
+	trainCards := make([]int, len(listOfGameColors))
+	trainCards[Red] = 3
+	trainCards[Rainbow] = 2
+	return trackView(0, listOfTracks, trackStatus, trainCards, 45), 5
+}
+
+//every split of a payment between the color and rainbows is listed, the greedy one first, and the engine accepts every one of them
+func TestTrackPaymentsListEverySplit(t *testing.T) {
+	view, track := splitTestView()
+	want := []Payment{{Red, 3, 0}, {Red, 2, 1}, {Red, 1, 2}}
+	payments := trackPayments(view, track)
+	if !reflect.DeepEqual(payments, want) {
+		t.Fatalf("the payments for track %d are %v, expected %v", track, payments, want)
+	}
+	if payments[0] != paymentFor(view, track, Red) {
+		t.Errorf("the first payment %v isn't the one paymentFor picks, %v", payments[0], paymentFor(view, track, Red))
+	}
+	for _, payment := range payments {
+		if rule, broken := trackLayRuleBroken(view, track, payment); broken {
+			t.Errorf("the listed payment %v is refused: %v", payment, rule)
+		}
+	}
+}
+
+func TestTrackPaymentsThatDontAddUpAreRefused(t *testing.T) {
+	view, track := splitTestView()
+	for _, c := range []struct {
+		payment Payment
+		rule    Rule
+	}{
+		{Payment{Red, 2, 0}, RuleWrongPaymentSize},
+		{Payment{Red, 2, 2}, RuleWrongPaymentSize},
+		{Payment{Red, 4, -1}, RuleWrongPaymentSize},
+		{Payment{Red, 0, 3}, RuleNotEnoughTrainCards},
+	} {
+		if rule, broken := trackLayRuleBroken(view, track, c.payment); !broken || rule != c.rule {
+			t.Errorf("paying %v broke rule %v (%v), expected %v", c.payment, rule, broken, c.rule)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/geckods/ticket-to-ride/ttr"
+)
+
+//the strongest BeaverPlayer parameters found by the GA so far
+var tunedBeaverParameters = []float64{0.44454935033352205, 0.5, 0.107653, 0.010350716892173813, 0.8450304277220828, 0.08914744929999999, 0.00013917876699999997, 0.2525800021749184, 1}
+
+//playerNames are the built in players that can be seated with -players; any name registered with ttr.Register can be seated too
+var playerNames = []string{"basic", "zebra", "aardvark", "beaver", "human", "web", "bot"}
+
+//consoleInput is the terminal, shared by every human seat: a scanner of its own would read ahead into the other seats' answers
+var consoleInput = bufio.NewScanner(os.Stdin)
+
+//newPlayerByName makes a fresh player for one seat of one game; web seats play in the visualizer, and are only available if it is running
+func newPlayerByName(name string, web *webSeats) (Player, error) {
+	switch name {
+	case "basic":
+		return &BasicPlayer{}, nil
+	case "zebra":
+		return &ZebraBot{}, nil
+	case "aardvark":
+		return &AardvarkPlayer{}, nil
+	case "beaver":
+		player := &BeaverPlayer{}
+		player.setScoringParameters(tunedBeaverParameters)
+		return player, nil
+	case "human":
+		return newHumanConsolePlayer(consoleInput, os.Stdout), nil
+	case "web":
+		if web == nil {
+			return nil, errors.New("a web seat plays in the visualizer, which needs -visualize")
+		}
+		return newWebSocketHumanPlayer(web), nil
+	case "bot":
+		if *botCommand == "" {
+			return nil, errors.New("a bot seat needs the bot's command line, given with -bot")
+		}
+		return newProcessPlayer(strings.Fields(*botCommand), *botTimeout), nil
+	}
+	if !itemExists(ttr.RegisteredPlayers(), name) {
+		return nil, fmt.Errorf("unknown player %q, expected one of %v or a registered bot", name, playerNames)
+	}
+	//	a registered bot can still be refused, for one, if it was written against another version of the API
+	return newRegisteredPlayer(name)
+}
+
+//parseLineup reads a comma separated list of player names, one per seat, like "human,zebra,beaver,beaver"
+func parseLineup(spec string, web *webSeats) ([]string, error) {
+	names := strings.Split(spec, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+		if _, err := newPlayerByName(names[i], web); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+//newLineup makes fresh players for the seats of a lineup from parseLineup, held to the time limits if there are any
+func newLineup(names []string, web *webSeats, limits TimeLimits) []Player {
+	players := make([]Player, len(names))
+	for i, name := range names {
+		//	every name was checked by parseLineup
+		players[i], _ = newPlayerByName(name, web)
+		if limits.limited() {
+			players[i] = newTimedPlayer(players[i], limits)
+		}
+	}
+	return players
+}
+
+//lineupForMode is the lineup given with -players, or the mode's own lineup with the bot given with -bot in its last two seats
+//either way, the constants must allow a game with that many players
+func lineupForMode(defaultLineup string, web *webSeats, constants GameConstants) ([]string, error) {
+	spec := *lineupSpec
+	if spec == "" {
+		spec = defaultLineup
+	}
+	names, err := parseLineup(spec, web)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := constants.forPlayers(len(names)); err != nil {
+		return nil, err
+	}
+	if *lineupSpec == "" && *botCommand != "" {
+		names[len(names)-2], names[len(names)-1] = "bot", "bot"
+	}
+	return names, nil
+}
+
+//alternatingLineup seats the players in turn until numPlayers seats are filled, like "zebra,beaver,zebra" for zebra and beaver in three seats
+func alternatingLineup(numPlayers int, names ...string) string {
+	seats := make([]string, numPlayers)
+	for i := range seats {
+		seats[i] = names[i%len(names)]
+	}
+	return strings.Join(seats, ",")
+}
@@ -0,0 +1,504 @@
+package main
+
+import "sort"
+
+//the longest continuous path of a player is the longest trail through its tracks: it may pass through a city many times, but uses each track once
+//
+//longestTrail finds it exactly, one connected network of the player's tracks at a time, using these facts about a longest trail:
+//- in a network without loops, it is the way between the two cities furthest apart
+//- it can't end in a city where the player has an even number of tracks, unless it uses every track of the network: an unused track there would make it longer
+//- so with at most two such odd cities, it uses every track, and is found directly, as an Euler trail
+//- otherwise it runs from one odd city to another, and never ends in a city with two tracks, so chains of tracks through such cities can be taken as one
+//- the tracks it leaves out pair up all the other odd cities, so it is at most as long as all the tracks less the shortest ways to pair them up
+//- once a trail is found that long, there is nothing left to search
+//
+//this is for the dense networks of a late game: the brute force search in longestpathcheck.go walks every trail from every city, which grows exponentially with the loops of a network
+//there, BenchmarkLongestTrail is two to three times as fast as BenchmarkBruteForce on average, and the slowest networks of -verifyLongestPath take many times less
+//on the few scattered tracks of an early game, splitting them into networks costs more than the brute force search does, but both take a few microseconds
+
+const MAXPAIREDODDCITIES = 8 //pairing up more odd cities every way takes longer than the search it saves
+
+//trailEdge is a chain of a player's tracks between two cities, passing only through cities where the player has exactly two tracks
+//the cities are numbered within their trailGraph
+type trailEdge struct {
+	from, to int
+	length   int
+	tracks   []int //the tracks of the chain in order, from `from` to `to`
+}
+
+//trailStep is a trailEdge on a trail, walked away from a city
+type trailStep struct {
+	edge int
+	from int
+}
+
+//bitset is a set of small non-negative integers
+type bitset []uint64
+
+func newBitset(size int) bitset {
+	return make(bitset, (size+63)/64)
+}
+
+func (b bitset) has(i int) bool {
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+func (b bitset) clear(i int) {
+	b[i/64] &^= 1 << uint(i%64)
+}
+
+//longestTrail finds the longest continuous path of a player's tracks
+//it returns its length in trains, and its tracks in order from one end to the other
+func longestTrail(trackList []Track, trackStatus []int, player int) (int, []int) {
+	numCities := 0
+	for _, t := range trackList {
+		if int(t.d1) >= numCities {
+			numCities = int(t.d1) + 1
+		}
+		if int(t.d2) >= numCities {
+			numCities = int(t.d2) + 1
+		}
+	}
+
+	//	the tracks of the player at each city
+	adjacency := make([][]int, numCities)
+	for i, t := range trackList {
+		if trackStatus[i] == player {
+			adjacency[t.d1] = append(adjacency[t.d1], i)
+			adjacency[t.d2] = append(adjacency[t.d2], i)
+		}
+	}
+
+	bestLength := 0
+	var bestTrail []int
+	for _, network := range trackNetworks(trackList, adjacency) {
+		if network.length <= bestLength {
+			//	even all of its tracks can't beat the best trail so far
+			continue
+		}
+		length, trail := network.longestTrail(trackList, adjacency)
+		if length > bestLength {
+			bestLength, bestTrail = length, trail
+		}
+	}
+	return bestLength, bestTrail
+}
+
+//trackNetwork is a connected set of a player's tracks
+type trackNetwork struct {
+	cities []Destination
+	tracks []int
+	length int //of all of its tracks
+}
+
+//trackNetworks splits a player's tracks into connected networks
+func trackNetworks(trackList []Track, adjacency [][]int) []trackNetwork {
+	numTracks := 0
+	for _, tracks := range adjacency {
+		numTracks += len(tracks)
+	}
+	numTracks /= 2
+
+	networks := make([]trackNetwork, 0, numTracks)
+	seen := make([]bool, len(adjacency))
+	seenTrack := make([]bool, len(trackList))
+	toVisit := make([]Destination, 0, len(adjacency))
+	//	the networks share these, as no city or track is in two of them
+	cities := make([]Destination, 0, len(adjacency))
+	tracks := make([]int, 0, numTracks)
+	for i := range adjacency {
+		start := Destination(i)
+		if seen[start] || len(adjacency[start]) == 0 {
+			continue
+		}
+		firstCity, firstTrack, length := len(cities), len(tracks), 0
+		seen[start] = true
+		toVisit = append(toVisit, start)
+		for len(toVisit) > 0 {
+			city := toVisit[len(toVisit)-1]
+			toVisit = toVisit[:len(toVisit)-1]
+			cities = append(cities, city)
+			for _, track := range adjacency[city] {
+				if !seenTrack[track] {
+					seenTrack[track] = true
+					tracks = append(tracks, track)
+					length += trackList[track].length
+				}
+				next := otherEnd(trackList[track], city)
+				if !seen[next] {
+					seen[next] = true
+					toVisit = append(toVisit, next)
+				}
+			}
+		}
+		networks = append(networks, trackNetwork{
+			cities: cities[firstCity:len(cities):len(cities)],
+			tracks: tracks[firstTrack:len(tracks):len(tracks)],
+			length: length,
+		})
+	}
+	return networks
+}
+
+func otherEnd(t Track, d Destination) Destination {
+	if d == t.d1 {
+		return t.d2
+	}
+	return t.d1
+}
+
+//treeDiameter finds the longest trail of a network without loops: it runs between the two cities furthest apart
+//the city furthest from any city is at one end of it, and the city furthest from that is at the other
+func (n trackNetwork) treeDiameter(trackList []Track, adjacency [][]int) (int, []int) {
+	if len(n.tracks) == 1 {
+		return n.length, n.tracks
+	}
+	distance := make([]int, len(adjacency))
+	parentTrack := make([]int, len(adjacency))
+	from, _ := n.furthest(trackList, adjacency, n.cities[0], distance, parentTrack)
+	to, length := n.furthest(trackList, adjacency, from, distance, parentTrack)
+	trail := make([]int, 0)
+	for at := to; at != from; at = otherEnd(trackList[parentTrack[at]], at) {
+		trail = append(trail, parentTrack[at])
+	}
+	return length, trail
+}
+
+//furthest finds the city of a network without loops that is furthest from start, and its distance
+//it fills in the distance to each city, and the track each city was reached by, by Destination
+func (n trackNetwork) furthest(trackList []Track, adjacency [][]int, start Destination, distance []int, parentTrack []int) (Destination, int) {
+	distance[start] = 0
+	parentTrack[start] = -1
+	furthest := start
+	toVisit := []Destination{start}
+	for len(toVisit) > 0 {
+		city := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+		if distance[city] > distance[furthest] {
+			furthest = city
+		}
+		for _, track := range adjacency[city] {
+			if track == parentTrack[city] {
+				continue
+			}
+			next := otherEnd(trackList[track], city)
+			distance[next] = distance[city] + trackList[track].length
+			parentTrack[next] = track
+			toVisit = append(toVisit, next)
+		}
+	}
+	return furthest, distance[furthest]
+}
+
+//longestTrail finds the longest trail of a network
+func (n trackNetwork) longestTrail(trackList []Track, adjacency [][]int) (int, []int) {
+	if len(n.tracks) == len(n.cities)-1 {
+		return n.treeDiameter(trackList, adjacency)
+	}
+	odd := make([]Destination, 0)
+	for _, city := range n.cities {
+		if len(adjacency[city])%2 == 1 {
+			odd = append(odd, city)
+		}
+	}
+	if len(odd) <= 2 {
+		//	every track can be walked in one go, starting from an odd city if there is one
+		g := newTrailGraph(trackList, n, adjacency, false)
+		start := n.cities[0]
+		if len(odd) > 0 {
+			start = odd[0]
+		}
+		return n.length, g.expand(g.eulerTrail(g.number[start]))
+	}
+
+	g := newTrailGraph(trackList, n, adjacency, true)
+	search := newTrailSearch(g)
+	starts := make([]int, len(odd))
+	for i, city := range odd {
+		starts[i] = g.number[city]
+	}
+	bounds := trailBounds(starts, n.length, g.distances())
+	order := make([]int, len(starts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return bounds[order[i]] > bounds[order[j]] })
+
+	//	the most promising starts first, so that the others can often be skipped
+	for _, i := range order {
+		if bounds[i] <= search.bestLength {
+			break
+		}
+		search.bound = bounds[i]
+		search.extend(starts[i], 0)
+	}
+	return search.bestLength, g.expand(search.best)
+}
+
+//trailGraph is a network whose edges are single tracks, or chains of tracks through cities where the player has exactly two tracks
+type trailGraph struct {
+	number    []int //the number of each city at the end of an edge, by Destination
+	edges     []trailEdge
+	adjacency [][]int //the edges at each numbered city; a loop is listed once
+}
+
+//newTrailGraph makes a graph out of the tracks of a network, taking chains as single edges if chain is set
+//chains start and end at cities without exactly two tracks, so a network that is a single loop can't be chained
+func newTrailGraph(trackList []Track, n trackNetwork, adjacency [][]int, chain bool) *trailGraph {
+	g := &trailGraph{number: make([]int, len(adjacency))}
+	for _, city := range n.cities {
+		g.number[city] = -1
+	}
+	if !chain {
+		for _, track := range n.tracks {
+			t := trackList[track]
+			g.add(trailEdge{from: g.city(t.d1), to: g.city(t.d2), length: t.length, tracks: []int{track}})
+		}
+		return g
+	}
+
+	chained := make([]bool, len(trackList))
+	for _, track := range n.tracks {
+		for _, city := range [2]Destination{trackList[track].d1, trackList[track].d2} {
+			if chained[track] || len(adjacency[city]) == 2 {
+				continue
+			}
+			edge := trailEdge{from: g.city(city)}
+			at, next := city, track
+			for {
+				chained[next] = true
+				edge.tracks = append(edge.tracks, next)
+				edge.length += trackList[next].length
+				at = otherEnd(trackList[next], at)
+				if len(adjacency[at]) != 2 {
+					break
+				}
+				if adjacency[at][0] == next {
+					next = adjacency[at][1]
+				} else {
+					next = adjacency[at][0]
+				}
+			}
+			edge.to = g.city(at)
+			g.add(edge)
+		}
+	}
+	return g
+}
+
+//city returns the number of a city, numbering it if it has none yet
+func (g *trailGraph) city(d Destination) int {
+	if g.number[d] == -1 {
+		g.number[d] = len(g.adjacency)
+		g.adjacency = append(g.adjacency, nil)
+	}
+	return g.number[d]
+}
+
+func (g *trailGraph) add(edge trailEdge) {
+	g.adjacency[edge.from] = append(g.adjacency[edge.from], len(g.edges))
+	if edge.to != edge.from {
+		g.adjacency[edge.to] = append(g.adjacency[edge.to], len(g.edges))
+	}
+	g.edges = append(g.edges, edge)
+}
+
+func (g *trailGraph) otherEnd(edge int, city int) int {
+	if city == g.edges[edge].from {
+		return g.edges[edge].to
+	}
+	return g.edges[edge].from
+}
+
+//eulerTrail walks every edge exactly once, starting at city, which must be possible
+func (g *trailGraph) eulerTrail(city int) []trailStep {
+	used := newBitset(len(g.edges))
+	next := make([]int, len(g.adjacency)) //how far along its edges each city is
+	type visit struct {
+		city int
+		step trailStep //the edge the walk came in on, -1 for city
+	}
+	stack := []visit{{city: city, step: trailStep{edge: -1}}}
+	steps := make([]trailStep, 0)
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		edges := g.adjacency[top.city]
+		for next[top.city] < len(edges) && used.has(edges[next[top.city]]) {
+			next[top.city]++
+		}
+		if next[top.city] < len(edges) {
+			edge := edges[next[top.city]]
+			used.set(edge)
+			stack = append(stack, visit{city: g.otherEnd(edge, top.city), step: trailStep{edge: edge, from: top.city}})
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		if top.step.edge != -1 {
+			steps = append(steps, top.step)
+		}
+	}
+
+	//	the walk is found backwards
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
+
+//expand lists the tracks of a trail in order
+func (g *trailGraph) expand(trail []trailStep) []int {
+	tracks := make([]int, 0)
+	for _, step := range trail {
+		edge := g.edges[step.edge]
+		if step.from == edge.from {
+			tracks = append(tracks, edge.tracks...)
+			continue
+		}
+		for i := len(edge.tracks) - 1; i >= 0; i-- {
+			tracks = append(tracks, edge.tracks[i])
+		}
+	}
+	return tracks
+}
+
+//trailBounds returns, for each of the odd cities, how long a trail starting there could be at most
+//the tracks a trail leaves out must pair up every other odd city but the one it ends in, by ways at least as long as the distance between them
+func trailBounds(odd []int, total int, distances [][]int) []int {
+	if len(odd) > MAXPAIREDODDCITIES {
+		return nearestTrailBounds(odd, total, distances)
+	}
+
+	//	leftOut[cities] is the shortest way to pair up a set of the odd cities, or -1 for an odd number of them
+	leftOut := make([]int, 1<<uint(len(odd)))
+	for cities := 1; cities < len(leftOut); cities++ {
+		first := 0
+		for cities&(1<<uint(first)) == 0 {
+			first++
+		}
+		rest := cities &^ (1 << uint(first))
+		leftOut[cities] = -1
+		for other := first + 1; other < len(odd); other++ {
+			if rest&(1<<uint(other)) == 0 || leftOut[rest&^(1<<uint(other))] < 0 {
+				continue
+			}
+			cost := distances[odd[first]][odd[other]] + leftOut[rest&^(1<<uint(other))]
+			if leftOut[cities] < 0 || cost < leftOut[cities] {
+				leftOut[cities] = cost
+			}
+		}
+	}
+
+	bounds := make([]int, len(odd))
+	all := len(leftOut) - 1
+	for start := range odd {
+		fewest := -1
+		for end := range odd {
+			if cost := leftOut[all&^(1<<uint(start))&^(1<<uint(end))]; end != start && (fewest < 0 || cost < fewest) {
+				fewest = cost
+			}
+		}
+		bounds[start] = total - fewest
+	}
+	return bounds
+}
+
+//nearestTrailBounds is a quicker and looser trailBounds for many odd cities: each of them is paired up with the nearest other one, and each way can serve two of them
+func nearestTrailBounds(odd []int, total int, distances [][]int) []int {
+	nearest := make([]int, len(odd))
+	sum := 0
+	for i, city := range odd {
+		for j, other := range odd {
+			if j != i && (nearest[i] == 0 || distances[city][other] < nearest[i]) {
+				nearest[i] = distances[city][other]
+			}
+		}
+		sum += nearest[i]
+	}
+
+	bounds := make([]int, len(odd))
+	for start := range odd {
+		//	the trail may end at the odd city that would cost the most to leave odd
+		most := 0
+		for end := range odd {
+			if end != start && nearest[end] > most {
+				most = nearest[end]
+			}
+		}
+		bounds[start] = total - (sum-nearest[start]-most)/2
+	}
+	return bounds
+}
+
+//distances returns the shortest distance between every two cities of the graph, in trains
+func (g *trailGraph) distances() [][]int {
+	const unreachable = 1 << 30
+	distances := make([][]int, len(g.adjacency))
+	for i := range distances {
+		distances[i] = make([]int, len(g.adjacency))
+		for j := range distances[i] {
+			if i != j {
+				distances[i][j] = unreachable
+			}
+		}
+	}
+	for _, edge := range g.edges {
+		if edge.length < distances[edge.from][edge.to] {
+			distances[edge.from][edge.to] = edge.length
+			distances[edge.to][edge.from] = edge.length
+		}
+	}
+	for via := range distances {
+		for from := range distances {
+			for to := range distances {
+				if d := distances[from][via] + distances[via][to]; d < distances[from][to] {
+					distances[from][to] = d
+				}
+			}
+		}
+	}
+	return distances
+}
+
+//trailSearch tries the trails of a trailGraph, until it finds one as long as its bound
+type trailSearch struct {
+	graph *trailGraph
+	used  bitset
+	path  []trailStep
+	bound int //no trail can be longer than this
+
+	best       []trailStep
+	bestLength int
+}
+
+func newTrailSearch(g *trailGraph) *trailSearch {
+	return &trailSearch{graph: g, used: newBitset(len(g.edges))}
+}
+
+//extend tries every way on for the current trail, which is at city after length trains
+func (s *trailSearch) extend(city int, length int) {
+	if length > s.bestLength {
+		s.bestLength = length
+		s.best = append([]trailStep(nil), s.path...)
+	}
+	if s.bestLength >= s.bound {
+		return
+	}
+
+	for _, i := range s.graph.adjacency[city] {
+		if s.used.has(i) {
+			continue
+		}
+		s.used.set(i)
+		s.path = append(s.path, trailStep{edge: i, from: city})
+		s.extend(s.graph.otherEnd(i, city), length+s.graph.edges[i].length)
+		s.path = s.path[:len(s.path)-1]
+		s.used.clear(i)
+		if s.bestLength >= s.bound {
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+//testNetworks makes numNetworks random networks of the board's tracks for player 0, as large as a player can build, scattered or dense
+func testNetworks(b *Board, numNetworks int, dense bool, seed int64) [][]int {
+	rng := rand.New(rand.NewSource(seed))
+	maxTrains := b.gameConstants().NumStartingTrains
+	networks := make([][]int, numNetworks)
+	for i := range networks {
+		trains := maxTrains
+		if !dense {
+			trains = 1 + rng.Intn(maxTrains)
+		}
+		networks[i] = randomNetwork(b.Tracks, rng, trains, dense)
+	}
+	return networks
+}
+
+func TestLongestTrailMatchesBruteForce(t *testing.T) {
+	europe, err := loadBoard("maps/europe.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	numNetworks := 500
+	if testing.Short() {
+		numNetworks = 50
+	}
+
+	for _, b := range []*Board{usaBoard, europe} {
+		for _, dense := range []bool{false, true} {
+			for i, trackStatus := range testNetworks(b, numNetworks, dense, 1) {
+				length, trail := longestTrail(b.Tracks, trackStatus, 0)
+				if want := bruteForceLongestTrail(b.Tracks, trackStatus, 0); length != want {
+					t.Fatalf("network %d of the %s board (dense %v): the longest path is %d long, but longestTrail found %d; the tracks are %v", i, b.Name, dense, want, length, playerTracks(trackStatus, 0))
+				}
+				if err := checkTrail(b.Tracks, trackStatus, 0, length, trail); err != nil {
+					t.Fatalf("network %d of the %s board (dense %v): %v; the tracks are %v", i, b.Name, dense, err, playerTracks(trackStatus, 0))
+				}
+			}
+		}
+	}
+}
+
+//benchmarkLongestPath times a longest path search over the same networks of the USA and Europe boards as every other benchmark here
+func benchmarkLongestPath(b *testing.B, search func(trackList []Track, trackStatus []int, player int) int) {
+	europe, err := loadBoard("maps/europe.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, board := range []*Board{usaBoard, europe} {
+		for _, dense := range []bool{false, true} {
+			name := board.Name + "/scattered"
+			if dense {
+				name = board.Name + "/dense"
+			}
+			trackList, networks := board.Tracks, testNetworks(board, 100, dense, 1)
+			b.Run(name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					search(trackList, networks[i%len(networks)], 0)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkLongestTrail(b *testing.B) {
+	benchmarkLongestPath(b, func(trackList []Track, trackStatus []int, player int) int {
+		length, _ := longestTrail(trackList, trackStatus, player)
+		return length
+	})
+}
+
+func BenchmarkBruteForce(b *testing.B) {
+	benchmarkLongestPath(b, bruteForceLongestTrail)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+//bruteForceLongestTrail is the old exhaustive search for the length of a player's longest continuous path: from every city, it tries every way to walk the player's tracks
+//it gets slow on dense networks, and is only kept to check longestTrail against
+func bruteForceLongestTrail(trackList []Track, trackStatus []int, player int) int {
+	numCities := 0
+	for _, t := range trackList {
+		for _, d := range []Destination{t.d1, t.d2} {
+			if int(d) >= numCities {
+				numCities = int(d) + 1
+			}
+		}
+	}
+
+	//	First, create a playerAdjList, which selects only the tracks which belong to the player
+	playerAdjList := make([][]int, numCities)
+	for i, t := range trackList {
+		if trackStatus[i] == player {
+			playerAdjList[t.d1] = append(playerAdjList[t.d1], i)
+			playerAdjList[t.d2] = append(playerAdjList[t.d2], i)
+		}
+	}
+
+	seenEdge := make([]bool, len(trackList))
+	var walk func(x Destination, currLen int) int
+	walk = func(x Destination, currLen int) int {
+		longest := currLen
+		for _, edgeIndex := range playerAdjList[x] {
+			if !seenEdge[edgeIndex] {
+				seenEdge[edgeIndex] = true
+				if l := walk(otherEnd(trackList[edgeIndex], x), currLen+trackList[edgeIndex].length); l > longest {
+					longest = l
+				}
+				seenEdge[edgeIndex] = false
+			}
+		}
+		return longest
+	}
+
+	ans := 0
+	//	now, from each starting point, run the recursive computer
+	for i := 0; i < numCities; i++ {
+		if l := walk(Destination(i), 0); l > ans {
+			ans = l
+		}
+	}
+	return ans
+}
+
+//checkTrail returns an error unless trail is a continuous path of the player's tracks, using each track once, of the given length
+func checkTrail(trackList []Track, trackStatus []int, player int, length int, trail []int) error {
+	if len(trail) == 0 {
+		if length != 0 {
+			return fmt.Errorf("an empty path can't be %d long", length)
+		}
+		return nil
+	}
+
+	used := make(map[int]bool)
+	total := 0
+	for _, track := range trail {
+		if track < 0 || track >= len(trackList) || trackStatus[track] != player {
+			return fmt.Errorf("the path uses track %d, which isn't the player's", track)
+		}
+		if used[track] {
+			return fmt.Errorf("the path uses track %d twice", track)
+		}
+		used[track] = true
+		total += trackList[track].length
+	}
+	if total != length {
+		return fmt.Errorf("the path is %d long, not %d", total, length)
+	}
+
+	//	the path may start at either end of its first track
+	for _, at := range []Destination{trackList[trail[0]].d1, trackList[trail[0]].d2} {
+		continuous := true
+		for _, track := range trail {
+			t := trackList[track]
+			if t.d1 != at && t.d2 != at {
+				continuous = false
+				break
+			}
+			at = otherEnd(t, at)
+		}
+		if continuous {
+			return nil
+		}
+	}
+	return fmt.Errorf("the tracks %v aren't a continuous path", trail)
+}
+
+//randomNetwork gives the player up to numTrains trains worth of tracks of the board: scattered at random, or grown out from one city into a dense network
+func randomNetwork(trackList []Track, rng *rand.Rand, numTrains int, dense bool) []int {
+	trackStatus := make([]int, len(trackList))
+	for i := range trackStatus {
+		trackStatus[i] = -1
+	}
+	if !dense {
+		trains := 0
+		for _, track := range rng.Perm(len(trackList)) {
+			if trains+trackList[track].length <= numTrains {
+				trackStatus[track] = 0
+				trains += trackList[track].length
+			}
+		}
+		return trackStatus
+	}
+
+	reached := map[Destination]bool{trackList[rng.Intn(len(trackList))].d1: true}
+	for trains := 0; ; {
+		//	take a random free track out of the cities reached so far
+		frontier := make([]int, 0)
+		for i, t := range trackList {
+			if trackStatus[i] == -1 && (reached[t.d1] || reached[t.d2]) && trains+t.length <= numTrains {
+				frontier = append(frontier, i)
+			}
+		}
+		if len(frontier) == 0 {
+			return trackStatus
+		}
+		track := frontier[rng.Intn(len(frontier))]
+		trackStatus[track] = 0
+		reached[trackList[track].d1] = true
+		reached[trackList[track].d2] = true
+		trains += trackList[track].length
+	}
+}
+
+//trailTimes adds up how long a longest path search took over many networks
+type trailTimes struct {
+	networks int
+	total    time.Duration
+	longest  time.Duration
+}
+
+func (t *trailTimes) add(took time.Duration) {
+	t.networks++
+	t.total += took
+	if took > t.longest {
+		t.longest = took
+	}
+}
+
+func (t trailTimes) String() string {
+	if t.networks == 0 {
+		return "no networks"
+	}
+	return fmt.Sprintf("%v on average, at most %v", t.total/time.Duration(t.networks), t.longest)
+}
+
+//verifyLongestPaths checks longestTrail against the brute force search on random networks of the tracks of a board, of up to maxTrains trains, and times both
+//every other network is dense, which is where the brute force search is slowest
+func verifyLongestPaths(trackList []Track, maxTrains int, numNetworks int, seed int64) error {
+	rng := rand.New(rand.NewSource(seed))
+
+	//	by search, and then by whether the networks were dense
+	var times [2][2]trailTimes
+	for i := 0; i < numNetworks; i++ {
+		dense := i%2 == 1
+		trains := maxTrains
+		if !dense {
+			trains = 1 + rng.Intn(maxTrains)
+		}
+		trackStatus := randomNetwork(trackList, rng, trains, dense)
+		which := 0
+		if dense {
+			which = 1
+		}
+
+		start := time.Now()
+		length, trail := longestTrail(trackList, trackStatus, 0)
+		times[0][which].add(time.Since(start))
+
+		start = time.Now()
+		want := bruteForceLongestTrail(trackList, trackStatus, 0)
+		times[1][which].add(time.Since(start))
+
+		if length != want {
+			return fmt.Errorf("network %d (seed %d): the longest path is %d long, but longestTrail found %d; the tracks are %v", i, seed, want, length, playerTracks(trackStatus, 0))
+		}
+		if err := checkTrail(trackList, trackStatus, 0, length, trail); err != nil {
+			return fmt.Errorf("network %d (seed %d): %v; the tracks are %v", i, seed, err, playerTracks(trackStatus, 0))
+		}
+	}
+
+	fmt.Printf("checked %d networks of up to %d trains against the brute force search: all agree\n", numNetworks, maxTrains)
+	fmt.Printf("on scattered networks, longestTrail took %v, and the brute force search %v\n", times[0][0], times[1][0])
+	fmt.Printf("on dense networks of %d trains, longestTrail took %v, and the brute force search %v\n", maxTrains, times[0][1], times[1][1])
+	return nil
+}
+
+func playerTracks(trackStatus []int, player int) []int {
+	tracks := make([]int, 0)
+	for i, owner := range trackStatus {
+		if owner == player {
+			tracks = append(tracks, i)
+		}
+	}
+	return tracks
+}
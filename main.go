@@ -8,7 +8,9 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -17,65 +19,133 @@ var toLog *bool
 var consoleView *bool
 var toUseVisualizer *bool
 var toTrainGA *bool
+var toValidateMap *bool
+var toCheckTicketValues *bool
+var numTicketsToGenerate *int
+var ticketLengthsSpec *string
 var statisticsMode *bool
-var toGenerateGraphs bool
+var illegalMovePolicyName *string
+var keepTies *bool
+var verifyLongestPathNetworks *int
+var seed *int64
+var numGames *int
+var numWorkers *int
+var snapshotFile *string
+var resumeFile *string
+var recordFile *string
+var replayFile *string
+var botCommand *string
+var botTimeout *time.Duration
+var lineupSpec *string
+var numPlayers *int
+var mapName *string
+var gameBoard *Board //the board loaded from -map, which every game is played on
+var variantName *string
+var gameVariant Variant //the variant from -variant, which every game is played in
+var moveTimeLimit *time.Duration
+var gameTimeLimit *time.Duration
+var timeoutPolicyName *string
+
+//timeLimits are the time limits of every player, given with -moveTimeLimit, -gameTimeLimit and -timeoutPolicy
+func timeLimits() (TimeLimits, error) {
+	policy, err := parseTimeoutPolicy(*timeoutPolicyName)
+	return TimeLimits{PerCall: *moveTimeLimit, PerGame: *gameTimeLimit, Policy: policy}, err
+}
 
-var server *socketio.Server //may be required globally
+//boardConstants returns the constants of the standard game on the board from -map, in the variant from -variant
+func boardConstants() GameConstants {
+	constants, err := gameBoard.gameConstants().withVariant(gameVariant, gameBoard)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return constants
+}
 
-func gatherStatistics() {
-	constants := GameConstants{
-		NumColorCards:                       NUMCOLORCARDS,
-		NumRainbowCards:                     NUMRAINBOWCARDS,
-		NumStartingTrains:                   NUMSTARTINGTRAINS,
-		NumFaceUpTrainCards:                 NUMFACEUPTRAINCARDS,
-		NumGameColors:                       NUMGAMECOLORS,
-		NumInitialTrainCardsDealt:           NUMINITIALTRAINCARDSDEALT,
-		NumInitialDestinationTicketsOffered: NUMINITIALDESTINATIONTICKETSOFFERED,
-		NumInitialDestinationTicketsPicked:  NUMINITIALDESTINATIONTICKETSPICKED,
-		NumDestinationTicketsOffered:        NUMDESTINATIONTICKETSOFFERED,
-		NumDestinationTicketsPicked:         NUMDESTINATIONTICKETSPICKED,
-		LongestPathScore:                    LONGESTPATHSCORE,
-		NumPlayers:                          0,
-		NumTracks:                           0,
-		NumDestinations:                     NUMDESTINATIONS,
-		routeLengthScores:                   routeLengthScores,
+//generateTicketDeck prints a deck of -generateTickets tickets for the board from -map
+func generateTicketDeck() {
+	distribution, err := parseTicketLengths(*ticketLengthsSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tickets, err := generateTickets(gameBoard, *numTicketsToGenerate, distribution, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := printTickets(gameBoard, tickets); err != nil {
+		log.Fatal(err)
+	}
+}
+
+//seatExternalBots puts the bot given with -bot in the last two seats, in place of the players there
+//every game needs its own bots: each one runs its own copy of the program
+func seatExternalBots(players []Player) {
+	if *botCommand == "" {
+		return
 	}
+	for i := len(players) - 2; i < len(players); i++ {
+		players[i] = newProcessPlayer(strings.Fields(*botCommand), *botTimeout)
+	}
+}
 
+func gatherStatistics() {
+	constants := boardConstants()
+
+
+	illegalMovePolicy, err := parseIllegalMovePolicy(*illegalMovePolicyName)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	results := make(map[int]int)
 
-	for i:=0;i<1000;i++ {
-		fmt.Println(i)
+	//game i is played with seed baseSeed+i, so any game can be replayed with -seed and -numGames 1
+	baseSeed := *seed
+	if baseSeed == 0 {
+		baseSeed = rand.Int63()
+	}
 
+	lineup, err := lineupForMode(alternatingLineup(*numPlayers, "zebra", "aardvark"), nil, constants)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if itemExists(lineup, "human") {
+		log.Fatal("a human can only play single games: statistics mode plays many games at once")
+	}
+	limits, err := timeLimits()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gameResults := runGamesInParallel(*numGames, *numWorkers, constants, func(i int) (*Engine, []Player) {
 		e := Engine{}
 		e.OptimizerMode = true
-		players := make([]Player, 0)
-		player1 := ZebraBot{}
-		players = append(players, &player1)
-		player2 := AardvarkPlayer{}
-		//player2.setScoringParameters([]float64{0.44454935033352205 ,0.5 ,0.107653, 0.010350716892173813, 0.8450304277220828, 0.08914744929999999, 0.00013917876699999997, 0.2525800021749184, 1})
-		players = append(players, &player2)
-		player3 := ZebraBot{}
-		players = append(players, &player3)
-		player4 := AardvarkPlayer{}
-		//player4.setScoringParameters([]float64{0.44454935033352205 ,0.5 ,0.107653, 0.010350716892173813, 0.8450304277220828, 0.08914744929999999, 0.00013917876699999997, 0.2525800021749184, 1})
-		players = append(players, &player4)
-
-		winners := e.runGame(players, constants)
-
-		if len(winners)>1 {
+		e.IllegalMovePolicy = illegalMovePolicy
+		e.KeepTies = *keepTies
+		e.Board = gameBoard
+		e.Seed = baseSeed + int64(i)
+		return &e, newLineup(lineup, nil, limits)
+	})
+
+	for i, result := range gameResults {
+		fmt.Println(i, "seed", result.Seed)
+
+		winners := result.Winners
+		if len(winners) == 0 {
+			//	the game was aborted, or everybody was disqualified
+			results[-2]++
+		} else if len(winners)>1 {
+			//	still tied after the tie breakers, or -keepTies
 			results[-1]++
 		} else {
 			results[winners[0]]++
 		}
 	}
 
+	printSummary(gameResults)
 	fmt.Println(results)
 }
 
 func singleGameMode() {
-	toGenerateGraphs = (*toLog && !(*consoleView))||(*toUseVisualizer)
-
 	//logging related code
 	var myConfig zap.Config
 	if *toLog {
@@ -95,10 +165,13 @@ func singleGameMode() {
 
 	//visualizer related code
 	var wg *sync.WaitGroup
+	var server *socketio.Server
+	var web *webSeats //the seats of the people playing in the browser
 	numConnections := 0
 
 	if *toUseVisualizer {
 		server = socketio.NewServer(nil)
+		web = newWebSeats(server)
 
 		server.OnConnect("/", func(s socketio.Conn) error {
 			s.SetContext("")
@@ -114,6 +187,7 @@ func singleGameMode() {
 		//
 		server.OnDisconnect("/", func(s socketio.Conn, reason string) {
 			zap.S().Debug("closed", reason)
+			web.drop(s)
 		})
 
 		go server.Serve()
@@ -132,23 +206,7 @@ func singleGameMode() {
 		}()
 	}
 
-	constants := GameConstants{
-		NumColorCards:                       NUMCOLORCARDS,
-		NumRainbowCards:                     NUMRAINBOWCARDS,
-		NumStartingTrains:                   NUMSTARTINGTRAINS,
-		NumFaceUpTrainCards:                 NUMFACEUPTRAINCARDS,
-		NumGameColors:                       NUMGAMECOLORS,
-		NumInitialTrainCardsDealt:           NUMINITIALTRAINCARDSDEALT,
-		NumInitialDestinationTicketsOffered: NUMINITIALDESTINATIONTICKETSOFFERED,
-		NumInitialDestinationTicketsPicked:  NUMINITIALDESTINATIONTICKETSPICKED,
-		NumDestinationTicketsOffered:        NUMDESTINATIONTICKETSOFFERED,
-		NumDestinationTicketsPicked:         NUMDESTINATIONTICKETSPICKED,
-		LongestPathScore:                    LONGESTPATHSCORE,
-		NumPlayers:                          0,
-		NumTracks:                           0,
-		NumDestinations:                     NUMDESTINATIONS,
-		routeLengthScores:                   routeLengthScores,
-	}
+	constants := boardConstants()
 
 	//These are for BeaverPlayer OLD, without sampling code
 	//{0.5, 0.5, 0.1, 0.18, 1, 0.1, 0.001, 0.01}
@@ -177,21 +235,85 @@ func singleGameMode() {
 	// {0.17442904328297634 ,0.5915, 0.9348259641822315, 0.01922275994260851, 0.7675062831300927, 0.014214893109791652, 0.00028403829999999996, 0.4256978025995064, 1}
 	// {0.5259018814445566 ,0.6997445 ,0.06528327057446887 ,0.013455931959825957 ,0.4495446974003105, 0.009054886910937282, 0.00036924979, 0.29798846181965444, 1}
 
+	illegalMovePolicy, err := parseIllegalMovePolicy(*illegalMovePolicyName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lineup, err := lineupForMode(alternatingLineup(*numPlayers, "zebra", "beaver"), web, constants)
+	if err != nil {
+		log.Fatal(err)
+	}
+	limits, err := timeLimits()
+	if err != nil {
+		log.Fatal(err)
+	}
+	players := newLineup(lineup, web, limits)
+	defer closePlayers(players)
+
 	e := Engine{}
 	e.OptimizerMode = true
-	players := make([]Player, 0)
-	player1 := ZebraBot{}
-	players = append(players, &player1)
-	player2 := BeaverPlayer{}
-	player2.setScoringParameters([]float64{0.44454935033352205 ,0.5 ,0.107653, 0.010350716892173813, 0.8450304277220828, 0.08914744929999999, 0.00013917876699999997, 0.2525800021749184, 1})
-	players = append(players, &player2)
-	player3 := ZebraBot{}
-	players = append(players, &player3)
-	player4 := BeaverPlayer{}
-	player4.setScoringParameters([]float64{0.44454935033352205 ,0.5 ,0.107653, 0.010350716892173813, 0.8450304277220828, 0.08914744929999999, 0.00013917876699999997, 0.2525800021749184, 1})
-	players = append(players, &player4)
+	e.IllegalMovePolicy = illegalMovePolicy
+	e.KeepTies = *keepTies
+	e.Board = gameBoard
+	e.Seed = *seed
+	if e.Seed == 0 {
+		e.Seed = rand.Int63()
+	}
+	e.addObserver(scorePrinter{})
+	if *toLog {
+		e.addObserver(&zapObserver{})
+	}
+	if *toUseVisualizer {
+		e.addObserver(&visualizerObserver{server: server, hideSecrets: itemExists(lineup, "web")})
+	}
+	if (*toLog && !(*consoleView)) || (*toUseVisualizer) {
+		graphs := &graphObserver{engine: &e, toLog: *toLog && !(*consoleView)}
+		if *toUseVisualizer {
+			graphs.server = server
+		}
+		e.addObserver(graphs)
+	}
+	if *snapshotFile != "" {
+		e.addObserver(&snapshotWriter{engine: &e, filename: *snapshotFile})
+	}
 
-	winners := e.runGame(players, constants)
+	if *resumeFile != "" && *recordFile != "" {
+		log.Fatal("a resumed game can't be recorded: its record wouldn't hold the decisions made before the snapshot")
+	}
+
+	var result GameResult
+	if *resumeFile != "" {
+		file, err := os.Open(*resumeFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		snapshot, err := readSnapshot(file, snapshotFormatForFile(*resumeFile))
+		file.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		result, err = e.resumeGame(snapshot, players)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else if *recordFile != "" {
+		record, recordingPlayers := startRecording(&e, constants, players)
+		result = e.runGame(recordingPlayers, constants)
+
+		file, err := os.Create(*recordFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = writeGameRecord(file, record)
+		file.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		result = e.runGame(players, constants)
+	}
+	winners := result.Winners
 
 
 	//player2.setScoringParameters([]float64{0.44454935033352205 ,0.5 ,0.107653, 0.010350716892173813, 0.8450304277220828, 0.08914744929999999, 0.00013917876699999997, 0.2525800021749184, 1})
@@ -203,6 +325,27 @@ func singleGameMode() {
 	//player4.setScoringParameters([]float64{0.44454935033352205 ,0.5 ,0.107653, 0.010350716892173813, 0.8450304277220828, 0.08914744929999999, 0.00013917876699999997, 0.2525800021749184, 1})
 	//players = append(players, &player4)
 
+	for _, violation := range result.RuleViolations {
+		fmt.Println(violation.Err, "- policy applied:", violation.PolicyApplied)
+	}
+
+	for i, player := range result.Players {
+		fmt.Printf("Player %d: %d for routes, %d for tickets (%d of %d completed), %d for the longest path (%d long), %d trains left\n",
+			i, player.RoutePoints, player.TicketPoints, player.TicketsCompleted(), len(player.Tickets), player.LongestPathBonus, player.LongestPath, player.TrainsLeft)
+		if constants.GlobetrotterScore > 0 {
+			fmt.Printf("Player %d: %d for completing the most tickets\n", i, player.GlobetrotterBonus)
+		}
+		if constants.NumStations > 0 {
+			fmt.Printf("Player %d: %d for stations, using the tracks %v of other players\n", i, player.StationPoints, player.BorrowedTracks)
+		}
+	}
+	fmt.Println("The game ended after", result.NumTurns, "turns:", result.EndReason)
+	fmt.Println("The seed was", result.Seed)
+
+	if len(winners) == 0 {
+		fmt.Println("The game ended without a winner")
+	}
+
 	for _,winner := range winners {
 		fmt.Println("The winner was", winner)
 	}
@@ -216,9 +359,37 @@ func singleGameMode() {
 }
 
 
+//replays a recorded game through the engine, and checks that every step goes the way it was recorded
+func replayMode() {
+	file, err := os.Open(*replayFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	record, err := readGameRecord(file)
+	file.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e, err := replayGame(record, scorePrinter{})
+	if e != nil {
+		for _, violation := range e.ruleViolations {
+			fmt.Println(violation.Err, "- policy applied:", violation.PolicyApplied)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("The replay of", len(record.Decisions), "decisions with seed", record.Seed, "matches the record")
+	for _,winner := range record.Winners {
+		fmt.Println("The winner was", winner)
+	}
+}
+
 func main() {
 
-	//seed random number generator
+	//seed random number generator: this only picks the seeds of games and drives the GA, every game has its own seeded random source
 	rand.Seed(time.Now().UTC().UnixNano())
 
 
@@ -227,16 +398,61 @@ func main() {
 	consoleView = flag.Bool("console", true, "Whether to log the operation to console or to file. (default true, to console)")
 	toUseVisualizer = flag.Bool("visualize", false, "Whether or not to send data on a socket for visualization")
 	toTrainGA = flag.Bool("trainGA", false, "Whether or not put the program in training GA mode. This will not log to console or visualize")
+	toValidateMap = flag.Bool("validateMap", false, "Check the board from -map for everything the engine takes on trust, print every problem found, and exit with an error if there were any")
+	toCheckTicketValues = flag.Bool("checkTicketValues", false, "Work out what every ticket of the board from -map is worth from the trains its shortest route takes, print them, and exit with an error if any ticket's printed value is different")
+	numTicketsToGenerate = flag.Int("generateTickets", 0, "Generate a balanced deck of this many tickets for the board from -map, worth what their shortest routes take, and print it as the Tickets of a map file, shuffled with -seed")
+	ticketLengthsSpec = flag.String("ticketLengths", DEFAULTTICKETLENGTHS, "How the lengths of the tickets -generateTickets makes are spread out: ranges of trains, each with a weight")
 	statisticsMode = flag.Bool("statisticsMode", false, "Whether to run 1000 games for statistics. This will not log to console or visualize")
+	seed = flag.Int64("seed", 0, "The seed to play the game with, to replay a game. In statistics mode, the seed of the first game. (default 0, pick a random seed)")
+	numGames = flag.Int("numGames", 1000, "How many games to run in statistics mode")
+	numWorkers = flag.Int("workers", 0, "How many games to run at the same time in statistics mode and GA training (default 0, one per CPU)")
+	snapshotFile = flag.String("snapshot", "", "Save a snapshot of the game to this file at the start of every turn: .gob files are binary, anything else is JSON")
+	resumeFile = flag.String("resume", "", "Resume the game saved in this snapshot file, with a fresh set of players")
+	recordFile = flag.String("record", "", "Record the game's seed and every decision of the players to this file, to be replayed with -replay")
+	replayFile = flag.String("replay", "", "Replay the game recorded in this file, checking that every step and the final scores match the record")
+	lineupSpec = flag.String("players", "", "Who sits in each seat, comma separated, like human,zebra,beaver,beaver: basic, zebra, aardvark, beaver, human, bot (the program given with -bot) or the name of a registered bot. (default -numPlayers seats of ZebraBots and BeaverPlayers, or AardvarkPlayers in statistics mode)")
+	mapName = flag.String("map", DEFAULTMAPNAME, "The board to play on: usa for the built in board, or a map file like maps/usa.json. A resumed or replayed game is played on the board it was saved with")
+	variantName = flag.String("variant", "standard", "The variant to play, modeled on USA 1910 with approximate decks: standard, expanded (five more tickets, and the globetrotter bonus instead of the longest path bonus), hubcities (only tickets to the six biggest cities) or alltickets (every ticket, and both bonuses). GA training trains for it, and writes its results to a file of its own")
+	numPlayers = flag.Int("numPlayers", 4, "How many players sit at the table, from 2 to 5, unless -players seats them one by one")
+	botCommand = flag.String("bot", "", "The command line of a bot program speaking the protocol in bots/PROTOCOL.md, seated in the last two seats of every game (which needs -numPlayers of at least 4 in GA training), including GA training games, unless -players seats it with bot")
+	botTimeout = flag.Duration("botTimeout", DEFAULTBOTTIMEOUT, "How long a bot program may take to answer one question before it is stopped")
+	moveTimeLimit = flag.Duration("moveTimeLimit", 0, "How long a player may take over a single decision, or over being told about the game, before it is out of time (default 0, no limit)")
+	gameTimeLimit = flag.Duration("gameTimeLimit", 0, "How long a player may take over all of its calls in a game before it is out of time (default 0, no limit)")
+	timeoutPolicyName = flag.String("timeoutPolicy", "fallback", "How a player that is out of time plays the rest of its game: fallback (the engine draws cards for it) or forfeit (every answer is illegal, and -illegalMovePolicy decides the cost)")
+	verifyLongestPathNetworks = flag.Int("verifyLongestPath", 0, "Check the longest path search against a brute force search on this many random networks of tracks, and time both, starting from -seed")
+	keepTies = flag.Bool("keepTies", false, "Let every player tied on points win, instead of breaking ties by the most completed destination tickets and then the longest path")
+	illegalMovePolicyName = flag.String("illegalMovePolicy", "retry", "What to do when a player makes an illegal move: retry, forfeit, disqualify or abort")
 	flag.Parse()
 
+	var err error
+	gameBoard, err = loadBoard(*mapName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	gameVariant, err = parseVariant(*variantName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	if *toTrainGA {
 		//GA stuff
-		toLog = new(bool)
-		consoleView = new(bool)
-		toUseVisualizer = new(bool)
-		toGenerateGraphs = false
 		optimizeBeaverParametersWithGeneticAlgorithm()
+	} else if *toValidateMap {
+		if !validateMap(gameBoard, boardConstants()) {
+			os.Exit(1)
+		}
+	} else if *toCheckTicketValues {
+		if checkTicketValues(gameBoard) > 0 {
+			os.Exit(1)
+		}
+	} else if *numTicketsToGenerate > 0 {
+		generateTicketDeck()
+	} else if *verifyLongestPathNetworks > 0 {
+		if err := verifyLongestPaths(gameBoard.Tracks, boardConstants().NumStartingTrains, *verifyLongestPathNetworks, *seed); err != nil {
+			log.Fatal(err)
+		}
+	} else if *replayFile != "" {
+		replayMode()
 	} else if *statisticsMode {
 		gatherStatistics()
 	} else {
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//validateBoard checks everything about a board that the engine takes on trust, whether the board is built in or was loaded from a map file, and returns every problem it finds
+//constants are the constants the board is played with, which the train cards are checked against
+func validateBoard(b *Board, constants GameConstants) []error {
+	problems := make([]error, 0)
+	numCities := len(b.DestinationNames)
+	if numCities == 0 {
+		return append(problems, fmt.Errorf("the board has no cities"))
+	}
+	city := func(d Destination) string {
+		if d < 0 || int(d) >= numCities {
+			return "unknown city " + strconv.Itoa(int(d))
+		}
+		return b.DestinationNames[d]
+	}
+	known := func(d Destination) bool {
+		return d >= 0 && int(d) < numCities
+	}
+
+	for i, t := range b.Tracks {
+		if t.idx != i {
+			problems = append(problems, fmt.Errorf("track %d says it is track %d", i, t.idx))
+		}
+		if !known(t.d1) || !known(t.d2) {
+			problems = append(problems, fmt.Errorf("track %d goes from %s to %s, but the board has %d cities", i, city(t.d1), city(t.d2), numCities))
+		} else if t.d1 == t.d2 {
+			problems = append(problems, fmt.Errorf("track %d goes from %s to itself", i, city(t.d1)))
+		}
+		if t.length < 1 || t.length >= len(b.RouteLengthScores) {
+			problems = append(problems, fmt.Errorf("track %d from %s to %s is %d long, but the route scores only go from 1 to %d", i, city(t.d1), city(t.d2), t.length, len(b.RouteLengthScores)-1))
+		}
+		if t.c < 0 || (t.c >= Rainbow && t.c != Other) {
+			problems = append(problems, fmt.Errorf("track %d from %s to %s has color %d, which is neither a card color nor grey", i, city(t.d1), city(t.d2), t.c))
+		} else if t.length > constants.NumColorCards+constants.NumRainbowCards {
+			problems = append(problems, fmt.Errorf("track %d from %s to %s is %d long, but there are only %d cards of a color and %d rainbows to pay for it", i, city(t.d1), city(t.d2), t.length, constants.NumColorCards, constants.NumRainbowCards))
+		}
+	}
+
+	problems = append(problems, validateDoubleRoutes(b, city)...)
+
+	//	every city must be reachable from every other over the tracks, or some tickets can never be completed
+	component := boardComponents(b)
+	for d := range b.DestinationNames {
+		if component[d] != component[0] {
+			problems = append(problems, fmt.Errorf("%s can't be reached from %s over the tracks", b.DestinationNames[d], b.DestinationNames[0]))
+		}
+	}
+
+	for _, deck := range b.ticketDecks() {
+		for i, ticket := range deck.tickets {
+			switch {
+			case !known(ticket.d1) || !known(ticket.d2):
+				problems = append(problems, fmt.Errorf("%s %d goes from %s to %s, but the board has %d cities", deck.name, i, city(ticket.d1), city(ticket.d2), numCities))
+			case ticket.d1 == ticket.d2:
+				problems = append(problems, fmt.Errorf("%s %d goes from %s to itself", deck.name, i, city(ticket.d1)))
+			case component[ticket.d1] != component[ticket.d2]:
+				problems = append(problems, fmt.Errorf("%s %d from %s to %s can never be completed: no tracks join them", deck.name, i, city(ticket.d1), city(ticket.d2)))
+			}
+			if ticket.points <= 0 {
+				problems = append(problems, fmt.Errorf("%s %d from %s to %s is worth %d points", deck.name, i, city(ticket.d1), city(ticket.d2), ticket.points))
+			}
+		}
+	}
+
+	problems = append(problems, validateTrainCards(constants)...)
+
+	for _, name := range b.DestinationNames {
+		pos, ok := b.Positions[name]
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s has no position to draw it at", name))
+			continue
+		}
+		if err := checkPosition(pos); err != nil {
+			problems = append(problems, fmt.Errorf("the position of %s: %v", name, err))
+		}
+	}
+	positioned := make([]string, 0, len(b.Positions))
+	for name := range b.Positions {
+		positioned = append(positioned, name)
+	}
+	sort.Strings(positioned)
+	for _, name := range positioned {
+		if !itemExists(b.DestinationNames, name) {
+			problems = append(problems, fmt.Errorf("there is a position for %s, which isn't a city", name))
+		}
+	}
+	return problems
+}
+
+//ticketDeck is one of the decks of tickets of a board, named for messages about its tickets
+type ticketDeck struct {
+	name    string
+	tickets []DestinationTicket
+}
+
+//ticketDecks returns every deck of tickets the board has
+func (b *Board) ticketDecks() []ticketDeck {
+	decks := []ticketDeck{{"ticket", b.Tickets}}
+	if len(b.LongTickets) > 0 {
+		decks = append(decks, ticketDeck{"long ticket", b.LongTickets})
+	}
+	for _, variant := range []Variant{ExpandedDeckGame, HubCitiesGame} {
+		if tickets, ok := b.TicketDecks[variant]; ok {
+			decks = append(decks, ticketDeck{variant.String() + " ticket", tickets})
+		}
+	}
+	return decks
+}
+
+//validateDoubleRoutes checks that every double route is two or more tracks, each in no other double route, that all join the same two cities and are as long as each other
+func validateDoubleRoutes(b *Board, city func(Destination) string) []error {
+	problems := make([]error, 0)
+	inDoubleRoute := make(map[int]int)
+	for i, group := range b.DoubleRoutes {
+		if len(group) < 2 {
+			problems = append(problems, fmt.Errorf("double route %d has %d tracks, not two or more", i, len(group)))
+		}
+		for _, track := range group {
+			if track < 0 || track >= len(b.Tracks) {
+				problems = append(problems, fmt.Errorf("double route %d has track %d, but the board has %d tracks", i, track, len(b.Tracks)))
+				continue
+			}
+			if other, ok := inDoubleRoute[track]; ok {
+				problems = append(problems, fmt.Errorf("track %d is in double routes %d and %d", track, other, i))
+				continue
+			}
+			inDoubleRoute[track] = i
+
+			first, t := b.Tracks[group[0]], b.Tracks[track]
+			if !((t.d1 == first.d1 && t.d2 == first.d2) || (t.d1 == first.d2 && t.d2 == first.d1)) {
+				problems = append(problems, fmt.Errorf("double route %d has track %d from %s to %s, and track %d from %s to %s", i, group[0], city(first.d1), city(first.d2), track, city(t.d1), city(t.d2)))
+			} else if t.length != first.length {
+				problems = append(problems, fmt.Errorf("double route %d from %s to %s has track %d of length %d, and track %d of length %d", i, city(t.d1), city(t.d2), group[0], first.length, track, t.length))
+			}
+		}
+	}
+	return problems
+}
+
+//boardComponents numbers the groups of cities joined by tracks, indexed by Destination; tracks to unknown cities are left out
+func boardComponents(b *Board) []int {
+	component := make([]int, len(b.DestinationNames))
+	for d := range component {
+		component[d] = -1
+	}
+	adjacent := make([][]Destination, len(b.DestinationNames))
+	for _, t := range b.Tracks {
+		if t.d1 >= 0 && t.d2 >= 0 && int(t.d1) < len(adjacent) && int(t.d2) < len(adjacent) {
+			adjacent[t.d1] = append(adjacent[t.d1], t.d2)
+			adjacent[t.d2] = append(adjacent[t.d2], t.d1)
+		}
+	}
+
+	numComponents := 0
+	for start := range component {
+		if component[start] != -1 {
+			continue
+		}
+		component[start] = numComponents
+		stack := []Destination{Destination(start)}
+		for len(stack) > 0 {
+			d := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, next := range adjacent[d] {
+				if component[next] == -1 {
+					component[next] = numComponents
+					stack = append(stack, next)
+				}
+			}
+		}
+		numComponents++
+	}
+	return component
+}
+
+//validateTrainCards builds the deck the way the engine does, and checks that it has NumColorCards of every color and NumRainbowCards rainbows
+func validateTrainCards(constants GameConstants) []error {
+	problems := make([]error, 0)
+	if constants.NumGameColors != len(listOfGameColors) {
+		return append(problems, fmt.Errorf("there are %d game colors, but the engine deals cards of %d", constants.NumGameColors, len(listOfGameColors)))
+	}
+	if constants.NumColorCards < 1 || constants.NumRainbowCards < 0 {
+		return append(problems, fmt.Errorf("there must be cards of every color, but there are %d of each color and %d rainbows", constants.NumColorCards, constants.NumRainbowCards))
+	}
+
+	e := Engine{gameConstants: constants, rng: rand.New(rand.NewSource(0))}
+	e.initializePileOfTrainCards(make([]int, constants.NumGameColors))
+	counts := make([]int, constants.NumGameColors)
+	for _, c := range e.pileOfTrainCards {
+		counts[c]++
+	}
+	for _, c := range listOfGameColors {
+		want := constants.NumColorCards
+		if c == Rainbow {
+			want = constants.NumRainbowCards
+		}
+		if counts[c] != want {
+			problems = append(problems, fmt.Errorf("the deck has %d %s cards, not %d", counts[c], stringColors[c], want))
+		}
+	}
+	if total := (constants.NumGameColors-1)*constants.NumColorCards + constants.NumRainbowCards; len(e.pileOfTrainCards) != total {
+		problems = append(problems, fmt.Errorf("the deck has %d cards, not %d", len(e.pileOfTrainCards), total))
+	}
+	return problems
+}
+
+//checkPosition checks that a position is "x,y", as graphviz reads it
+func checkPosition(pos string) error {
+	coordinates := strings.Split(pos, ",")
+	if len(coordinates) != 2 {
+		return fmt.Errorf("%q isn't x,y", pos)
+	}
+	for _, coordinate := range coordinates {
+		if _, err := strconv.ParseFloat(coordinate, 64); err != nil {
+			return fmt.Errorf("%q isn't x,y", pos)
+		}
+	}
+	return nil
+}
+
+//validateMap checks a board, and prints every problem with it; it says whether there were none
+func validateMap(b *Board, constants GameConstants) bool {
+	problems := validateBoard(b, constants)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("the %s board has %d problems\n", b.Name, len(problems))
+		return false
+	}
+	fmt.Printf("the %s board is valid: %d cities, %d tracks and %d tickets\n", b.Name, len(b.DestinationNames), len(b.Tracks), len(b.Tickets))
+	return true
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	socketio "github.com/googollee/go-socket.io"
+	"go.uber.org/zap"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+//describeEvent turns an event into the EVENT name, the message and the fields that the zap logger and the visualizer report
+//destinationNames are the city names of the board, as announced by GameStarted
+func describeEvent(event GameEvent, destinationNames []string) (string, string, []zap.Field) {
+	switch ev := event.(type) {
+	case GameStarted:
+		return "GAME_SEED", "Starting a game with seed " + strconv.FormatInt(ev.Seed, 10),
+			[]zap.Field{zap.Int64("SEED", ev.Seed), zap.Int("NUM_PLAYERS", ev.NumPlayers)}
+	case TurnStarted:
+		return "TURN_START", "It is the turn of player" + strconv.Itoa(ev.Player),
+			[]zap.Field{zap.Int("PLAYER", ev.Player)}
+	case MoveChosen:
+		if ev.Move == 0 {
+			return "DECIDE_PICK_UP_CARDS", "Player " + strconv.Itoa(ev.Player) + " has decided to pick up cards",
+				[]zap.Field{zap.Int("PLAYER", ev.Player)}
+		} else if ev.Move == 1 {
+			return "DECIDE_LAY_TRACK", "Player " + strconv.Itoa(ev.Player) + " has decided to lay tracks",
+				[]zap.Field{zap.Int("PLAYER", ev.Player)}
+		} else if ev.Move == 3 {
+			return "DECIDE_BUILD_STATION", "Player " + strconv.Itoa(ev.Player) + " has decided to build a station",
+				[]zap.Field{zap.Int("PLAYER", ev.Player)}
+		}
+		return "DECIDE_PICK_UP_DESTINATION_TICKET", "Player " + strconv.Itoa(ev.Player) + " has decided to pick up destination tickets",
+			[]zap.Field{zap.Int("PLAYER", ev.Player)}
+	case CardDrawn:
+		return "GIVING_TRAIN_CARD", "Giving a card of Color " + stringColors[ev.Color] + " to player " + strconv.Itoa(ev.Player),
+			[]zap.Field{zap.String("COLOR", stringColors[ev.Color]), zap.Int("PLAYER", ev.Player), zap.Bool("HIDDEN", ev.Hidden)}
+	case FaceUpCardsReshuffled:
+		return "RESHUFFLING_FACE_UP_TRAIN_CARDS", strconv.Itoa(ev.NumRainbows) + " face up cards are rainbows, discarding the face up cards and dealing new ones",
+			[]zap.Field{zap.Int("NUM_RAINBOW", ev.NumRainbows)}
+	case TicketsOffered:
+		return "OFFERING_DESTINATION_TICKETS", "Offering destination tickets " + describeTickets(ev.Tickets, destinationNames) + " to player " + strconv.Itoa(ev.Player) + ", who must keep " + strconv.Itoa(ev.MinKept),
+			[]zap.Field{zap.String("TICKETS", describeTickets(ev.Tickets, destinationNames)), zap.Int("MIN_KEPT", ev.MinKept), zap.Int("PLAYER", ev.Player)}
+	case TicketsKept:
+		return "GIVING_DESTINATION_TICKET", "Giving destination tickets " + describeTickets(ev.Tickets, destinationNames) + " to player " + strconv.Itoa(ev.Player),
+			[]zap.Field{zap.String("TICKETS", describeTickets(ev.Tickets, destinationNames)), zap.Int("PLAYER", ev.Player)}
+	case TrackClaimed:
+		return "LAYING_TRACK", "Player " + strconv.Itoa(ev.Player) + " has laid down a track from " + destinationNames[ev.From] + " to " + destinationNames[ev.To] + " costing " + strconv.Itoa(ev.NumColored) + " train cards of color " + stringColors[ev.Color] + " and " + strconv.Itoa(ev.NumRainbows) + " rainbow Cards.",
+			[]zap.Field{
+				zap.String("DEST_1", destinationNames[ev.From]),
+				zap.String("DEST_2", destinationNames[ev.To]),
+				zap.String("PRIMARY_COLOR", stringColors[ev.Color]),
+				zap.Int("NUM_PRIMARY", ev.NumColored),
+				zap.Int("NUM_RAINBOW", ev.NumRainbows),
+				zap.Int("PLAYER", ev.Player),
+			}
+	case TunnelCardsDrawn:
+		outcome := " and paid them"
+		if !ev.Paid {
+			outcome = " and gave up the tunnel"
+		}
+		if ev.Extra.NumColored+ev.Extra.NumRainbows == 0 {
+			outcome = ""
+		}
+		return "DRAWING_TUNNEL_CARDS", "Player " + strconv.Itoa(ev.Player) + " turned over " + describeColors(ev.Drawn) + " for the tunnel from " + destinationNames[ev.From] + " to " + destinationNames[ev.To] + ", which cost " + strconv.Itoa(ev.Extra.NumColored+ev.Extra.NumRainbows) + " more cards" + outcome,
+			[]zap.Field{
+				zap.String("DEST_1", destinationNames[ev.From]),
+				zap.String("DEST_2", destinationNames[ev.To]),
+				zap.String("DRAWN", describeColors(ev.Drawn)),
+				zap.Int("NUM_PRIMARY", ev.Extra.NumColored),
+				zap.Int("NUM_RAINBOW", ev.Extra.NumRainbows),
+				zap.Bool("PAID", ev.Paid),
+				zap.Int("PLAYER", ev.Player),
+			}
+	case StationBuilt:
+		return "BUILDING_STATION", "Player " + strconv.Itoa(ev.Player) + " has built a station in " + destinationNames[ev.City] + " costing " + strconv.Itoa(ev.NumColored) + " train cards of color " + stringColors[ev.Color] + " and " + strconv.Itoa(ev.NumRainbows) + " rainbow Cards.",
+			[]zap.Field{
+				zap.String("CITY", destinationNames[ev.City]),
+				zap.String("PRIMARY_COLOR", stringColors[ev.Color]),
+				zap.Int("NUM_PRIMARY", ev.NumColored),
+				zap.Int("NUM_RAINBOW", ev.NumRainbows),
+				zap.Int("PLAYER", ev.Player),
+			}
+	case FinalRoundStarted:
+		return "FINAL_ROUND", "Player " + strconv.Itoa(ev.Player) + " has " + strconv.Itoa(ev.NumTrains) + " trains left, the final round has started",
+			[]zap.Field{zap.Int("PLAYER", ev.Player), zap.Int("NUM_TRAINS", ev.NumTrains)}
+	case IllegalMoveMade:
+		return "ILLEGAL_MOVE", ev.Violation.Err.Error() + ", applying policy " + ev.Violation.PolicyApplied.String(),
+			[]zap.Field{
+				zap.Int("PLAYER", ev.Violation.Err.Player),
+				zap.Int("MOVE", ev.Violation.Err.Move),
+				zap.String("RULE", ev.Violation.Err.Rule.String()),
+				zap.String("DETAIL", ev.Violation.Err.Detail),
+				zap.String("POLICY", ev.Violation.PolicyApplied.String()),
+			}
+	case GameScored:
+		return "GAME_SCORED", "The scores are " + fmt.Sprint(ev.Scores) + " and the winners are " + fmt.Sprint(ev.Winners),
+			[]zap.Field{zap.Ints("SCORES", ev.Scores), zap.Ints("WINNERS", ev.Winners)}
+	}
+	return "UNKNOWN_EVENT", fmt.Sprintf("%#v", event), nil
+}
+
+func describeTickets(tickets []DestinationTicket, destinationNames []string) string {
+	description := ""
+	for i, ticket := range tickets {
+		if i > 0 {
+			description += ", "
+		}
+		description += destinationNames[ticket.d1] + "-" + destinationNames[ticket.d2] + " (" + strconv.Itoa(ticket.points) + ")"
+	}
+	return "[" + description + "]"
+}
+
+func describeColors(colors []GameColor) string {
+	description := ""
+	for i, c := range colors {
+		if i > 0 {
+			description += ", "
+		}
+		description += stringColors[c]
+	}
+	return "[" + description + "]"
+}
+
+//zapObserver logs every event with the global zap logger
+type zapObserver struct {
+	destinationNames []string
+}
+
+func (z *zapObserver) observe(event GameEvent) {
+	if started, ok := event.(GameStarted); ok {
+		z.destinationNames = started.DestinationNames
+	}
+	eventName, message, fields := describeEvent(event, z.destinationNames)
+	zap.L().Info("Engine: "+message, append([]zap.Field{zap.String("EVENT", eventName)}, fields...)...)
+}
+
+//visualizerObserver sends every event to the visualizer
+type visualizerObserver struct {
+	server           *socketio.Server
+	destinationNames []string
+	hideSecrets      bool //when people play in the browser, it mustn't show them the cards drawn from the deck and the tickets of the other players
+}
+
+func (v *visualizerObserver) observe(event GameEvent) {
+	if started, ok := event.(GameStarted); ok {
+		v.destinationNames = started.DestinationNames
+	}
+	_, message, _ := describeEvent(event, v.destinationNames)
+	if v.hideSecrets {
+		switch ev := event.(type) {
+		case CardDrawn:
+			if ev.Hidden {
+				message = "Player " + strconv.Itoa(ev.Player) + " drew a card from the deck"
+			}
+		case TicketsOffered:
+			message = "Offering " + strconv.Itoa(len(ev.Tickets)) + " destination tickets to player " + strconv.Itoa(ev.Player) + ", who must keep " + strconv.Itoa(ev.MinKept)
+		case TicketsKept:
+			message = "Player " + strconv.Itoa(ev.Player) + " kept " + strconv.Itoa(len(ev.Tickets)) + " destination tickets"
+		}
+	}
+	v.server.BroadcastToNamespace("/", "ENGINE_UPDATE", "Engine: "+message)
+}
+
+//scorePrinter prints the final scores of a game to stdout
+type scorePrinter struct{}
+
+func (scorePrinter) observe(event GameEvent) {
+	if scored, ok := event.(GameScored); ok {
+		for i, sc := range scored.Scores {
+			fmt.Println("Player", i, "scored", sc)
+		}
+		for i, timing := range scored.Timings {
+			fmt.Println("Player", i, "took", timing.Total, "over", timing.Calls, "calls, the longest of them", timing.Longest)
+			if timing.TimedOut {
+				fmt.Println("Player", i, "ran out of time, and", timing.Abandoned, "of its calls were abandoned")
+			}
+		}
+	}
+}
+
+//graphObserver renders the board with graphviz at the start of every turn and once the game is scored, whenever it changed
+//once the game is scored, the longest paths are drawn wider
+//the board is read from the engine, so it can only watch a single engine
+type graphObserver struct {
+	engine    *Engine
+	toLog     bool             //log the graphviz source with zap
+	server    *socketio.Server //if not nil, render a png for the visualizer and tell it to reload
+	vizString string
+}
+
+func (g *graphObserver) observe(event GameEvent) {
+	var longestPaths [][]int
+	switch ev := event.(type) {
+	case TurnStarted:
+	case GameScored:
+		longestPaths = ev.LongestPaths
+	default:
+		return
+	}
+
+	newString := g.engine.getGraphVizString(longestPaths)
+	if newString == g.vizString {
+		return
+	}
+	g.vizString = newString
+
+	if g.toLog {
+		zap.L().Info("Logging Graph",
+			zap.String("EVENT", "GRAPH"),
+			zap.String("GRAPH", g.vizString),
+		)
+	}
+
+	if g.server != nil {
+		//	write graph to file
+		g.engine.writeGraphToFile("visualizer/graph_pics/graph", g.vizString)
+		//	generate the png, and the svg the visualizer shows, whose tracks and cities can be clicked
+		renderGraph("png")
+		renderGraph("svg")
+
+		g.server.BroadcastToNamespace("/", "GRAPH_UPDATE")
+	}
+}
+
+//renderGraph renders the graph written for the visualizer with neato, in the given output format
+func renderGraph(format string) {
+	cmd := exec.Command("neato", "visualizer/graph_pics/graph", "-T"+format)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		zap.S().Fatal(err)
+	}
+	file, err := os.Create("visualizer/graph_pics/graph." + format)
+	if err != nil {
+		panic("failed creating file")
+	}
+	defer file.Close()
+	_, err = file.Write(out)
+	if err != nil {
+		panic("failed writing to file")
+	}
+}
+
+//snapshotWriter saves a snapshot of the game at the start of every turn, overwriting the previous one
+type snapshotWriter struct {
+	engine   *Engine
+	filename string
+}
+
+func (w *snapshotWriter) observe(event GameEvent) {
+	if _, ok := event.(TurnStarted); !ok {
+		return
+	}
+
+	file, err := os.Create(w.filename)
+	if err != nil {
+		panic("failed creating file")
+	}
+	defer file.Close()
+	err = writeSnapshot(file, w.engine.snapshot(), snapshotFormatForFile(w.filename))
+	if err != nil {
+		panic("failed writing to file")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+)
+
+//gameSetup builds the engine and the players for game number gameNumber
+//it is called on the goroutine that plays the game, so it must not share mutable state with other games
+type gameSetup func(gameNumber int) (*Engine, []Player)
+
+//runGamesInParallel plays numGames games on numWorkers goroutines, and returns the result of every game indexed by game number
+//numWorkers <= 0 means one worker per CPU
+//every engine owns its decks, board and random source, so games don't share any mutable state; the engines should not log
+func runGamesInParallel(numGames, numWorkers int, constants GameConstants, setup gameSetup) []GameResult {
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	if numWorkers > numGames {
+		numWorkers = numGames
+	}
+
+	results := make([]GameResult, numGames)
+	gameNumbers := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for gameNumber := range gameNumbers {
+				e, players := setup(gameNumber)
+				//each game writes only its own slot, so no lock is needed
+				results[gameNumber] = e.runGame(players, constants)
+				closePlayers(players)
+			}
+		}()
+	}
+
+	for gameNumber := 0; gameNumber < numGames; gameNumber++ {
+		gameNumbers <- gameNumber
+	}
+	close(gameNumbers)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+//playSeededGames plays numGames games of zebras and aardvarks on a board with numWorkers goroutines, game i with seed 1000+i
+func playSeededGames(b *Board, numGames, numWorkers int) []GameResult {
+	return runGamesInParallel(numGames, numWorkers, b.gameConstants(), func(i int) (*Engine, []Player) {
+		e := Engine{}
+		e.OptimizerMode = true
+		e.IllegalMovePolicy = ForfeitTurnOnIllegalMove
+		e.Board = b
+		e.Seed = 1000 + int64(i)
+		return &e, []Player{&ZebraBot{}, &AardvarkPlayer{}, &ZebraBot{}, &AardvarkPlayer{}}
+	})
+}
+
+//games played at the same time must not change each other: run with -race, so that any state they share is caught too
+func TestParallelGamesMatchOneWorker(t *testing.T) {
+	europe, err := loadBoard("maps/europe.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	numGames := 16
+	if testing.Short() {
+		numGames = 4
+	}
+
+	for _, b := range []*Board{usaBoard, europe} {
+		oneWorker := playSeededGames(b, numGames, 1)
+		manyWorkers := playSeededGames(b, numGames, 8)
+		for i := range oneWorker {
+			if !reflect.DeepEqual(oneWorker[i], manyWorkers[i]) {
+				t.Errorf("game %d on the %s board played differently on 8 workers than on 1:\n%+v\n%+v", i, b.Name, manyWorkers[i], oneWorker[i])
+			}
+		}
+	}
+}
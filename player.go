@@ -1,18 +1,24 @@
 package main
 
+import "math/rand"
+
 type Player interface {
-	initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants) //Tell the player what his number is and the total number of players, as well as the game settings
+	initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) //Tell the player what his number is and the total number of players, as well as the game settings, and give him his own random source so that games can be replayed
 
 	//players are stateful, so we may need to inform them of game events (in case they want to keep track of other players' hands or something)
 	informCardPickup(int, GameColor)   //inform this player that a player picked up a card of given color
 	informTrackLay(int, int)         //inform this player that a player placed a track
 	informDestinationTicketPickup(int) //inform this player that a player picked up a destination card
+	informFinalRound(int)              //inform this player that a player has dropped to few enough trains to start the final round: everybody, including that player, gets exactly one more turn
+	informGameResumed([]int)           //inform this player that the game was resumed from a snapshot, after being told its cards, tickets and the tracks laid so far: how many trains each player has left
 
-	informStatus([]int, []int) //called to inform the playstate before their turn
+	informStatus(PlayerView) //called to inform the playstate before their turn
 
-	askMove() int //Ask the player what move he wants to do: 0 is pick up cards, 1 is place Tracks, 2 is pick destination ticket
-	askPickup(int, []int) GameColor   //ask this player, given the gamestate, which card he wants to pick up
-	askTrackLay() (int, GameColor) //ask this player which track he wants to lay, and with what color
+	askMove() int //Ask the player what move he wants to do: 0 is pick up cards, 1 is place Tracks, 2 is pick destination ticket, 3 is build a station
+	askPickup(int, PlayerView) GameColor   //ask this player, given the gamestate, which card he wants to pick up
+	askTrackLay() (int, Payment) //ask this player which track he wants to lay, and how he pays for it: which color, and how many rainbows
+	askStation() (Destination, Payment) //ask this player which city he wants to build a station in, and how he pays for it, under the Europe rules
+	askTunnelPayment(int, []GameColor, Payment) bool //tell this player the cards turned over for the tunnel he is claiming, and ask whether he pays the extra cards they call for or gives up the claim
 
 	giveTrainCard(GameColor)                 //tell this player he has another card of given color
 	giveDestinationTicket(DestinationTicket) //tell this player has a destination card
@@ -0,0 +1,57 @@
+package main
+
+import "fmt"
+
+//PlayerCountRule changes some constants for games with between MinPlayers and MaxPlayers players; a zero field keeps the constant as it is
+type PlayerCountRule struct {
+	MinPlayers, MaxPlayers int
+
+	NumColorCards, NumRainbowCards, NumStartingTrains, NumInitialTrainCardsDealt, NumInitialDestinationTicketsOffered, NumInitialDestinationTicketsPicked int
+}
+
+func (r PlayerCountRule) appliesTo(numPlayers int) bool {
+	return numPlayers >= r.MinPlayers && (r.MaxPlayers == 0 || numPlayers <= r.MaxPlayers)
+}
+
+func override(constant *int, value int) {
+	if value != 0 {
+		*constant = value
+	}
+}
+
+//forPlayers returns the constants of a game with numPlayers players: it checks that the map can be played by that many, and applies the rules for that many in order
+//the rules only ever set constants, so constants that were already set up for a game come out the same
+func (c GameConstants) forPlayers(numPlayers int) (GameConstants, error) {
+	if numPlayers < 1 || (c.MinPlayers > 0 && numPlayers < c.MinPlayers) || (c.MaxPlayers > 0 && numPlayers > c.MaxPlayers) {
+		return c, fmt.Errorf("the game is for %d to %d players, not %d", c.MinPlayers, c.MaxPlayers, numPlayers)
+	}
+	c.NumPlayers = numPlayers
+
+	for _, rule := range c.PlayerCountRules {
+		if !rule.appliesTo(numPlayers) {
+			continue
+		}
+		override(&c.NumColorCards, rule.NumColorCards)
+		override(&c.NumRainbowCards, rule.NumRainbowCards)
+		override(&c.NumStartingTrains, rule.NumStartingTrains)
+		override(&c.NumInitialTrainCardsDealt, rule.NumInitialTrainCardsDealt)
+		override(&c.NumInitialDestinationTicketsOffered, rule.NumInitialDestinationTicketsOffered)
+		override(&c.NumInitialDestinationTicketsPicked, rule.NumInitialDestinationTicketsPicked)
+	}
+
+	//	every player must be dealt their cards and offered their tickets before the first turn
+	deck := (c.NumGameColors-1)*c.NumColorCards + c.NumRainbowCards
+	if dealt := numPlayers*c.NumInitialTrainCardsDealt + c.NumFaceUpTrainCards; dealt > deck {
+		return c, fmt.Errorf("%d players are dealt %d train cards, and turn up %d more, but there are only %d", numPlayers, numPlayers*c.NumInitialTrainCardsDealt, c.NumFaceUpTrainCards, deck)
+	}
+	if offered := numPlayers * c.NumInitialDestinationTicketsOffered; offered > c.NumDestinationTickets {
+		return c, fmt.Errorf("%d players are offered %d destination tickets, but there are only %d", numPlayers, offered, c.NumDestinationTickets)
+	}
+	if offered := numPlayers * c.NumInitialLongDestinationTicketsOffered; offered > c.NumLongDestinationTickets {
+		return c, fmt.Errorf("%d players are offered %d long destination tickets, but there are only %d", numPlayers, offered, c.NumLongDestinationTickets)
+	}
+	if offered := c.NumInitialDestinationTicketsOffered + c.NumInitialLongDestinationTicketsOffered; c.NumInitialDestinationTicketsPicked > offered {
+		return c, fmt.Errorf("players must keep %d of the %d destination tickets they are first offered", c.NumInitialDestinationTicketsPicked, offered)
+	}
+	return c, nil
+}
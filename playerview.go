@@ -0,0 +1,130 @@
+package main
+
+//PlayerView is what one player may know about the game when it is asked for a decision
+//the engine builds a new view for every decision out of copies of its state, and the accessors hand out copies again, so a player can't change the game through it
+type PlayerView struct {
+	player int
+
+	tracks           []Track
+	trackStatus      []int //who owns each track: -1 if it is free, CLOSEDTRACK if the double route rules keep this player off it
+	faceUpTrainCards []int //indexed by color
+
+	deckSize                  int
+	discardPileSize           int
+	destinationTicketPileSize int
+
+	//public counts, indexed by player
+	numTrainCards         []int
+	numTrains             []int
+	numDestinationTickets []int
+	stationsLeft          []int
+
+	stations []int //who built a station in each city, or -1 if nobody has
+
+	//this player's private hand
+	trainCards         []int //indexed by color
+	destinationTickets []DestinationTicket
+}
+
+//trackView is a view holding only what the track rules need, which a player can fill in from what it keeps track of itself
+func trackView(player int, tracks []Track, trackStatus []int, trainCards []int, numTrains int) PlayerView {
+	view := PlayerView{player: player, tracks: tracks, trackStatus: trackStatus, trainCards: trainCards}
+	view.numTrains = make([]int, player+1)
+	view.numTrains[player] = numTrains
+	return view
+}
+
+//Player is the number of the player whose view this is
+func (v PlayerView) Player() int {
+	return v.player
+}
+
+func (v PlayerView) NumPlayers() int {
+	return len(v.numTrains)
+}
+
+//Tracks is the board
+func (v PlayerView) Tracks() []Track {
+	return append([]Track(nil), v.tracks...)
+}
+
+//TrackStatus is who owns each track: -1 if it is free, CLOSEDTRACK if the double route rules keep this player off it
+func (v PlayerView) TrackStatus() []int {
+	return append([]int(nil), v.trackStatus...)
+}
+
+//FaceUpTrainCards is how many face up cards there are of each color
+func (v PlayerView) FaceUpTrainCards() []int {
+	return append([]int(nil), v.faceUpTrainCards...)
+}
+
+func (v PlayerView) DeckSize() int {
+	return v.deckSize
+}
+
+func (v PlayerView) DiscardPileSize() int {
+	return v.discardPileSize
+}
+
+//NumTrainCardsLeftToDraw is how many cards can still be drawn blind: the discard pile is shuffled into the deck when it runs out
+func (v PlayerView) NumTrainCardsLeftToDraw() int {
+	return v.deckSize + v.discardPileSize
+}
+
+func (v PlayerView) DestinationTicketPileSize() int {
+	return v.destinationTicketPileSize
+}
+
+//NumTrainCards is how many train cards player p holds
+func (v PlayerView) NumTrainCards(p int) int {
+	return v.numTrainCards[p]
+}
+
+//NumTrains is how many trains player p has left
+func (v PlayerView) NumTrains(p int) int {
+	return v.numTrains[p]
+}
+
+//NumDestinationTickets is how many destination tickets player p holds
+func (v PlayerView) NumDestinationTickets(p int) int {
+	return v.numDestinationTickets[p]
+}
+
+//TrainCards is this player's hand, indexed by color
+func (v PlayerView) TrainCards() []int {
+	return append([]int(nil), v.trainCards...)
+}
+
+//DestinationTickets is this player's destination tickets
+func (v PlayerView) DestinationTickets() []DestinationTicket {
+	return append([]DestinationTicket(nil), v.destinationTickets...)
+}
+
+//Stations is who built a station in each city: -1 if nobody has
+func (v PlayerView) Stations() []int {
+	return append([]int(nil), v.stations...)
+}
+
+//StationsLeft is how many stations player p can still build
+func (v PlayerView) StationsLeft(p int) int {
+	if p < 0 || p >= len(v.stationsLeft) {
+		return 0
+	}
+	return v.stationsLeft[p]
+}
+
+//stationCost is how many cards this player's next station takes: one for the first, two for the second, and so on
+func (v PlayerView) stationCost() int {
+	cost := 1
+	for _, owner := range v.stations {
+		if owner == v.player {
+			cost++
+		}
+	}
+	return cost
+}
+
+//LongestPath is the length of player p's longest continuous path, and its tracks in order from one end to the other
+func (v PlayerView) LongestPath(p int) (int, []int) {
+	return longestTrail(v.tracks, v.trackStatus, p)
+}
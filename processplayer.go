@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const BOTPROTOCOLVERSION = 1 //the version of the protocol in bots/PROTOCOL.md
+const DEFAULTBOTTIMEOUT = 5 * time.Second
+
+//processPlayer is a bot running as a separate program, which the engine talks to over its stdin and stdout, one JSON object per line
+//if the program crashes, answers late or breaks the protocol, it is stopped, and every later question gets an illegal answer, so the engine's illegal move policy decides what happens to its seat
+type processPlayer struct {
+	command []string
+	timeout time.Duration //how long the bot may take to answer one question
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan []byte   //the lines the bot writes, closed when its stdout is
+	done   chan struct{} //closed when the bot is stopped, so that nothing waits on it any more
+	failed error         //why the bot was stopped, nil while it plays
+	view   PlayerView    //the view of the last informStatus, which the payments the bot answers with are worked out from
+
+	stopOnce sync.Once //the program is killed by whichever of fail and kill comes first
+}
+
+//newProcessPlayer makes a player out of a command line; the program is started when the game is
+func newProcessPlayer(command []string, timeout time.Duration) *processPlayer {
+	return &processPlayer{command: command, timeout: timeout}
+}
+
+//botAnswer is a line the bot writes: only the fields of the question it answers are set
+type botAnswer struct {
+	ProtocolVersion *int
+	Name            string
+	Move            *int
+	Color           *GameColor
+	Rainbows        *int //for tracks and stations: how many of the cards paid are rainbows, if the bot chooses
+	Track           *int
+	Kept            []int
+	City            *Destination
+	Pay             *bool
+}
+
+//protocolTrack is a Track with exported fields, so that it can be encoded
+type protocolTrack struct {
+	Index       int
+	From, To    Destination
+	Color       GameColor
+	Length      int
+	Type        string //normal, tunnel or ferry
+	Locomotives int    //for ferries: how many of the cards paying for the track must be rainbows
+}
+
+//viewMessage is a PlayerView as it is sent to the bot; the board itself is only sent once, with initialize
+type viewMessage struct {
+	Player                    int
+	TrackStatus               []int
+	FaceUpTrainCards          []int
+	DeckSize                  int
+	DiscardPileSize           int
+	DestinationTicketPileSize int
+	NumTrainCards             []int
+	NumTrains                 []int
+	NumDestinationTickets     []int
+	StationsLeft              []int
+	Stations                  []int
+	TrainCards                []int
+	DestinationTickets        []TicketSnapshot
+}
+
+func newViewMessage(view PlayerView) viewMessage {
+	return viewMessage{
+		Player:                    view.player,
+		TrackStatus:               view.trackStatus,
+		FaceUpTrainCards:          view.faceUpTrainCards,
+		DeckSize:                  view.deckSize,
+		DiscardPileSize:           view.discardPileSize,
+		DestinationTicketPileSize: view.destinationTicketPileSize,
+		NumTrainCards:             view.numTrainCards,
+		NumTrains:                 view.numTrains,
+		NumDestinationTickets:     view.numDestinationTickets,
+		StationsLeft:              view.stationsLeft,
+		Stations:                  view.stations,
+		TrainCards:                view.trainCards,
+		DestinationTickets:        snapshotTickets(view.destinationTickets),
+	}
+}
+
+func (p *processPlayer) start() error {
+	if len(p.command) == 0 {
+		return errors.New("no command to run the bot with")
+	}
+	p.cmd = exec.Command(p.command[0], p.command[1:]...)
+	p.cmd.Stderr = os.Stderr //bots log to stderr, stdout is the protocol
+
+	stdin, err := p.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := p.cmd.Start(); err != nil {
+		return err
+	}
+	p.stdin = stdin
+
+	p.lines = make(chan []byte)
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.lines)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			select {
+			case p.lines <- append([]byte(nil), scanner.Bytes()...):
+			case <-p.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+//fail stops the bot for good
+func (p *processPlayer) fail(err error) {
+	if p.failed != nil {
+		return
+	}
+	p.failed = err
+	log.Printf("the bot %q stopped playing: %v", strings.Join(p.command, " "), err)
+	p.kill()
+}
+
+//kill stops the bot's program right away, without telling it the game is over
+//it may be called while another goroutine is still waiting on the bot, which then finds the bot gone and fails
+func (p *processPlayer) kill() {
+	if p.done == nil {
+		return
+	}
+	p.stopOnce.Do(func() {
+		close(p.done)
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+	})
+}
+
+//send writes a message to the bot
+//a bot that stops reading fills up its stdin, so the write gets as long as an answer does before the bot is stopped
+func (p *processPlayer) send(message map[string]interface{}) {
+	if p.failed != nil {
+		return
+	}
+	line, err := json.Marshal(message)
+	if err != nil {
+		p.fail(err)
+		return
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := p.stdin.Write(append(line, '\n'))
+		written <- err
+	}()
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-written:
+		if err != nil {
+			p.fail(fmt.Errorf("writing %s: %v", message["Type"], err))
+		}
+	case <-timer.C:
+		//	killing the bot ends the write
+		p.fail(fmt.Errorf("the bot didn't read %s within %v", message["Type"], p.timeout))
+	}
+}
+
+//ask writes a question to the bot and waits for its answer; it returns nil if the bot has failed
+func (p *processPlayer) ask(message map[string]interface{}) *botAnswer {
+	p.send(message)
+	if p.failed != nil {
+		return nil
+	}
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			p.fail(fmt.Errorf("the bot exited instead of answering %s", message["Type"]))
+			return nil
+		}
+		answer := &botAnswer{}
+		if err := json.Unmarshal(line, answer); err != nil {
+			p.fail(fmt.Errorf("the answer to %s isn't JSON: %v", message["Type"], err))
+			return nil
+		}
+		return answer
+	case <-timer.C:
+		//	a late answer would be read as the answer to the next question, so the bot can't go on
+		p.fail(fmt.Errorf("no answer to %s within %v", message["Type"], p.timeout))
+		return nil
+	}
+}
+
+func (p *processPlayer) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) {
+	tracks := make([]protocolTrack, len(trackList))
+	for i, t := range trackList {
+		tracks[i] = protocolTrack{Index: t.idx, From: t.d1, To: t.d2, Color: t.c, Length: t.length, Type: t.kind.String(), Locomotives: t.locomotives}
+	}
+	seed := rng.Int63()
+
+	if err := p.start(); err != nil {
+		p.fail(err)
+		return
+	}
+	answer := p.ask(map[string]interface{}{
+		"Type":              "initialize",
+		"ProtocolVersion":   BOTPROTOCOLVERSION,
+		"Player":            myNumber,
+		"Tracks":            tracks,
+		"Adjacency":         adjList,
+		"Constants":         constants,
+		"RouteLengthScores": constants.routeLengthScores,
+		"Seed":              seed,
+	})
+	if answer == nil {
+		return
+	}
+	if answer.ProtocolVersion == nil || *answer.ProtocolVersion != BOTPROTOCOLVERSION {
+		p.fail(fmt.Errorf("the bot doesn't speak version %d of the protocol", BOTPROTOCOLVERSION))
+	}
+}
+
+func (p *processPlayer) informCardPickup(player int, color GameColor) {
+	p.send(map[string]interface{}{"Type": "informCardPickup", "Player": player, "Color": color})
+}
+
+func (p *processPlayer) informTrackLay(player int, track int) {
+	p.send(map[string]interface{}{"Type": "informTrackLay", "Player": player, "Track": track})
+}
+
+func (p *processPlayer) informDestinationTicketPickup(player int) {
+	p.send(map[string]interface{}{"Type": "informDestinationTicketPickup", "Player": player})
+}
+
+func (p *processPlayer) informFinalRound(player int) {
+	p.send(map[string]interface{}{"Type": "informFinalRound", "Player": player})
+}
+
+func (p *processPlayer) informGameResumed(numTrains []int) {
+	p.send(map[string]interface{}{"Type": "informGameResumed", "NumTrains": numTrains})
+}
+
+func (p *processPlayer) informStatus(view PlayerView) {
+	p.view = view
+	p.send(map[string]interface{}{"Type": "informStatus", "View": newViewMessage(view), "LegalMoves": LegalMoves(view)})
+}
+
+func (p *processPlayer) askMove() int {
+	answer := p.ask(map[string]interface{}{"Type": "askMove"})
+	if answer == nil || answer.Move == nil {
+		return -1
+	}
+	return *answer.Move
+}
+
+func (p *processPlayer) askPickup(howManyLeft int, view PlayerView) GameColor {
+	answer := p.ask(map[string]interface{}{"Type": "askPickup", "HowManyLeft": howManyLeft, "View": newViewMessage(view), "LegalPickups": legalPickups(view, howManyLeft)})
+	if answer == nil || answer.Color == nil {
+		return -1
+	}
+	return *answer.Color
+}
+
+func (p *processPlayer) askTrackLay() (int, Payment) {
+	answer := p.ask(map[string]interface{}{"Type": "askTrackLay"})
+	if answer == nil || answer.Track == nil || answer.Color == nil {
+		return -1, Payment{Color: Other}
+	}
+	return *answer.Track, withRainbows(defaultTrackPayment(p.view, *answer.Track, *answer.Color), answer.Rainbows)
+}
+
+func (p *processPlayer) askStation() (Destination, Payment) {
+	answer := p.ask(map[string]interface{}{"Type": "askStation"})
+	if answer == nil || answer.City == nil || answer.Color == nil {
+		return -1, Payment{Color: Other}
+	}
+	return *answer.City, withRainbows(defaultStationPayment(p.view, *answer.Color), answer.Rainbows)
+}
+
+//withRainbows splits a payment the way the bot answered, if it did: that many rainbows, and the color for the rest
+func withRainbows(payment Payment, rainbows *int) Payment {
+	cost := payment.NumColored + payment.NumRainbows
+	if rainbows == nil || cost == 0 {
+		//	a payment of no cards is one the color can't make, which the engine refuses anyway
+		return payment
+	}
+	return Payment{Color: payment.Color, NumColored: cost - *rainbows, NumRainbows: *rainbows}
+}
+
+func (p *processPlayer) askTunnelPayment(track int, drawn []GameColor, extra Payment) bool {
+	answer := p.ask(map[string]interface{}{"Type": "askTunnelPayment", "Track": track, "Drawn": drawn, "Extra": extra})
+	if answer == nil || answer.Pay == nil {
+		//	not paying is always legal, so a bot that fails gives up the claim
+		return false
+	}
+	return *answer.Pay
+}
+
+func (p *processPlayer) giveTrainCard(color GameColor) {
+	p.send(map[string]interface{}{"Type": "giveTrainCard", "Color": color})
+}
+
+func (p *processPlayer) giveDestinationTicket(ticket DestinationTicket) {
+	p.send(map[string]interface{}{"Type": "giveDestinationTicket", "Ticket": TicketSnapshot{From: ticket.d1, To: ticket.d2, Points: ticket.points}})
+}
+
+func (p *processPlayer) offerDestinationTickets(tickets []DestinationTicket, minKept int) []int {
+	answer := p.ask(map[string]interface{}{"Type": "offerDestinationTickets", "Tickets": snapshotTickets(tickets), "MinKept": minKept})
+	if answer == nil {
+		return nil
+	}
+	return answer.Kept
+}
+
+//Close tells the bot the game is over, and makes sure its program exits
+func (p *processPlayer) Close() error {
+	if p.done == nil || p.failed != nil {
+		return nil
+	}
+	p.send(map[string]interface{}{"Type": "gameOver"})
+	if p.failed != nil {
+		return nil
+	}
+	p.failed = errors.New("the game is over")
+	close(p.done)
+	p.stdin.Close()
+
+	//	give the bot as long to exit as it has to answer a question
+	exited := make(chan error, 1)
+	go func() { exited <- p.cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(p.timeout):
+		p.cmd.Process.Kill()
+		return <-exited
+	}
+}
+
+//closePlayers lets the players that hold on to something, like a bot's program, let go of it once their game is over
+func closePlayers(players []Player) {
+	for _, p := range players {
+		if c, ok := p.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+)
+
+const RECORDVERSION = 2
+
+//DecisionKind says which question a player was answering
+type DecisionKind string
+
+const (
+	MoveDecision    DecisionKind = "move"    //askMove
+	PickupDecision  DecisionKind = "pickup"  //askPickup
+	TrackDecision   DecisionKind = "track"   //askTrackLay
+	TicketsDecision DecisionKind = "tickets" //offerDestinationTickets
+	StationDecision DecisionKind = "station" //askStation
+	TunnelDecision  DecisionKind = "tunnel"  //askTunnelPayment
+)
+
+//Decision is one answer a player gave the engine; only the fields of its kind are set
+type Decision struct {
+	Player   int
+	Kind     DecisionKind
+	Move     int         `json:",omitempty"`
+	Color    GameColor   `json:",omitempty"` //the card picked up, or the color a track or a station was paid with
+	Colored  int         `json:",omitempty"` //how many cards of that color paid for a track or a station
+	Rainbows int         `json:",omitempty"` //how many rainbows paid for a track or a station
+	Track    int         `json:",omitempty"`
+	Tickets  []int       `json:",omitempty"` //the indices of the offered tickets that were kept
+	City     Destination `json:",omitempty"` //where a station was built
+	Pay      bool        `json:",omitempty"` //whether the extra cards for a tunnel were paid
+}
+
+//payment is the payment of a track or a station the decision recorded
+func (d Decision) payment() Payment {
+	return Payment{Color: d.Color, NumColored: d.Colored, NumRainbows: d.Rainbows}
+}
+
+//GameRecord is everything needed to play a game again: the engine is deterministic given its seed and the players' decisions
+type GameRecord struct {
+	Version int
+	Map     string //the board, as loadBoard knows it
+
+	Constants         GameConstants
+	RouteLengthScores []int //GameConstants.routeLengthScores, which isn't exported
+	IllegalMovePolicy IllegalMovePolicy
+	KeepTies          bool
+	Seed              int64
+
+	Players   []string //the types of the recorded players, for information only
+	Decisions []Decision
+
+	IllegalMoves []RuleViolation //every move the engine refused, in order
+	Scores       []int           //the final scores, empty if the game was never scored
+	Winners      []int
+}
+
+//recordingPlayer passes everything through to a player, and writes down its decisions
+type recordingPlayer struct {
+	Player
+	number int
+	record *GameRecord
+}
+
+func (p *recordingPlayer) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) {
+	p.number = myNumber
+	p.Player.initialize(myNumber, trackList, adjList, constants, rng)
+}
+
+func (p *recordingPlayer) askMove() int {
+	move := p.Player.askMove()
+	p.record.Decisions = append(p.record.Decisions, Decision{Player: p.number, Kind: MoveDecision, Move: move})
+	return move
+}
+
+func (p *recordingPlayer) askPickup(howManyLeft int, view PlayerView) GameColor {
+	color := p.Player.askPickup(howManyLeft, view)
+	p.record.Decisions = append(p.record.Decisions, Decision{Player: p.number, Kind: PickupDecision, Color: color})
+	return color
+}
+
+func (p *recordingPlayer) askTrackLay() (int, Payment) {
+	track, payment := p.Player.askTrackLay()
+	p.record.Decisions = append(p.record.Decisions, Decision{Player: p.number, Kind: TrackDecision, Track: track, Color: payment.Color, Colored: payment.NumColored, Rainbows: payment.NumRainbows})
+	return track, payment
+}
+
+func (p *recordingPlayer) askStation() (Destination, Payment) {
+	city, payment := p.Player.askStation()
+	p.record.Decisions = append(p.record.Decisions, Decision{Player: p.number, Kind: StationDecision, City: city, Color: payment.Color, Colored: payment.NumColored, Rainbows: payment.NumRainbows})
+	return city, payment
+}
+
+func (p *recordingPlayer) askTunnelPayment(track int, drawn []GameColor, extra Payment) bool {
+	pay := p.Player.askTunnelPayment(track, drawn, extra)
+	p.record.Decisions = append(p.record.Decisions, Decision{Player: p.number, Kind: TunnelDecision, Pay: pay})
+	return pay
+}
+
+func (p *recordingPlayer) offerDestinationTickets(tickets []DestinationTicket, numToAccept int) []int {
+	accepted := p.Player.offerDestinationTickets(tickets, numToAccept)
+	p.record.Decisions = append(p.record.Decisions, Decision{Player: p.number, Kind: TicketsDecision, Tickets: append([]int(nil), accepted...)})
+	return accepted
+}
+
+//Close lets go of whatever the recorded player holds on to, like a bot's program
+func (p *recordingPlayer) Close() error {
+	if closer, ok := p.Player.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+//recordObserver fills in the seed and the result of a recorded game
+type recordObserver struct {
+	record *GameRecord
+}
+
+func (o recordObserver) observe(event GameEvent) {
+	switch ev := event.(type) {
+	case GameStarted:
+		o.record.Seed = ev.Seed
+	case IllegalMoveMade:
+		o.record.IllegalMoves = append(o.record.IllegalMoves, ev.Violation)
+	case GameScored:
+		o.record.Scores = ev.Scores
+		o.record.Winners = ev.Winners
+	}
+}
+
+//startRecording wraps the players so that their decisions are recorded, and has the engine fill in the rest of the record as the game goes
+//the returned players must be the ones passed to runGame
+func startRecording(e *Engine, constants GameConstants, players []Player) (*GameRecord, []Player) {
+	record := &GameRecord{
+		Version:           RECORDVERSION,
+		Map:               e.board().source,
+		Constants:         constants,
+		RouteLengthScores: constants.routeLengthScores,
+		IllegalMovePolicy: e.IllegalMovePolicy,
+		KeepTies:          e.KeepTies,
+	}
+
+	recordingPlayers := make([]Player, len(players))
+	for i, p := range players {
+		kind := p
+		if timed, ok := p.(*timedPlayer); ok {
+			//	the time limits don't change what a player is, but they still apply while recording
+			kind = timed.Player
+		}
+		record.Players = append(record.Players, reflect.TypeOf(kind).String())
+		recordingPlayers[i] = &recordingPlayer{Player: p, record: record}
+	}
+
+	e.addObserver(recordObserver{record: record})
+	return record, recordingPlayers
+}
+
+func writeGameRecord(w io.Writer, record *GameRecord) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(record)
+}
+
+func readGameRecord(r io.Reader) (GameRecord, error) {
+	var record GameRecord
+	err := json.NewDecoder(r).Decode(&record)
+	return record, err
+}
+
+//replayCursor hands out the decisions of a record in order
+type replayCursor struct {
+	decisions []Decision
+	next      int
+}
+
+//take returns the next decision, which must be the given player's answer to the given question
+func (c *replayCursor) take(player int, kind DecisionKind) Decision {
+	if c.next >= len(c.decisions) {
+		panic(fmt.Sprintf("the record has no decision left for player %d to answer %q", player, kind))
+	}
+	d := c.decisions[c.next]
+	if d.Player != player || d.Kind != kind {
+		panic(fmt.Sprintf("decision %d of the record is player %d answering %q, but the engine asked player %d for %q", c.next, d.Player, d.Kind, player, kind))
+	}
+	c.next++
+	return d
+}
+
+//replayPlayer answers with the decisions of a record
+type replayPlayer struct {
+	myNumber int
+	cursor   *replayCursor
+}
+
+func (r *replayPlayer) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) {
+	r.myNumber = myNumber
+}
+
+func (r *replayPlayer) informCardPickup(int, GameColor)         {}
+func (r *replayPlayer) informTrackLay(int, int)                 {}
+func (r *replayPlayer) informDestinationTicketPickup(int)       {}
+func (r *replayPlayer) informFinalRound(int)                    {}
+func (r *replayPlayer) informGameResumed([]int)                 {}
+func (r *replayPlayer) informStatus(PlayerView)                 {}
+func (r *replayPlayer) giveTrainCard(GameColor)                 {}
+func (r *replayPlayer) giveDestinationTicket(DestinationTicket) {}
+
+func (r *replayPlayer) askMove() int {
+	return r.cursor.take(r.myNumber, MoveDecision).Move
+}
+
+func (r *replayPlayer) askPickup(int, PlayerView) GameColor {
+	return r.cursor.take(r.myNumber, PickupDecision).Color
+}
+
+func (r *replayPlayer) askTrackLay() (int, Payment) {
+	d := r.cursor.take(r.myNumber, TrackDecision)
+	return d.Track, d.payment()
+}
+
+func (r *replayPlayer) askStation() (Destination, Payment) {
+	d := r.cursor.take(r.myNumber, StationDecision)
+	return d.City, d.payment()
+}
+
+func (r *replayPlayer) askTunnelPayment(int, []GameColor, Payment) bool {
+	return r.cursor.take(r.myNumber, TunnelDecision).Pay
+}
+
+func (r *replayPlayer) offerDestinationTickets([]DestinationTicket, int) []int {
+	return r.cursor.take(r.myNumber, TicketsDecision).Tickets
+}
+
+//replayGame plays a recorded game again through the engine, and checks that it ends the way the record says
+//it returns the replaying engine, so that its rule violations can be inspected
+func replayGame(record GameRecord, observers ...Observer) (e *Engine, err error) {
+	if record.Version != RECORDVERSION {
+		return nil, fmt.Errorf("record version %d is not supported, expected %d", record.Version, RECORDVERSION)
+	}
+	board, err := loadBoard(record.Map)
+	if err != nil {
+		return nil, fmt.Errorf("the record is of a game on map %q: %v", record.Map, err)
+	}
+
+	e = &Engine{}
+	e.Board = board
+	e.OptimizerMode = true
+	e.Seed = record.Seed
+	e.IllegalMovePolicy = record.IllegalMovePolicy
+	e.KeepTies = record.KeepTies
+	for _, o := range observers {
+		e.addObserver(o)
+	}
+	cursor := &replayCursor{decisions: record.Decisions}
+	players := make([]Player, len(record.Players))
+	for i := range players {
+		players[i] = &replayPlayer{cursor: cursor}
+	}
+
+	constants := record.Constants
+	constants.routeLengthScores = record.RouteLengthScores
+
+	//	a record that doesn't match the engine makes a replay player panic
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("the replay diverged from the record: %v", r)
+		}
+	}()
+	result := e.runGame(players, constants)
+	winners := result.Winners
+	scores := result.Scores()
+
+	if cursor.next != len(cursor.decisions) {
+		return e, fmt.Errorf("the game ended after %d of the %d recorded decisions", cursor.next, len(cursor.decisions))
+	}
+	if len(result.RuleViolations) != len(record.IllegalMoves) {
+		return e, fmt.Errorf("the replay refused %d moves, but the record says %d", len(result.RuleViolations), len(record.IllegalMoves))
+	}
+	for i := range result.RuleViolations {
+		if !reflect.DeepEqual(result.RuleViolations[i], record.IllegalMoves[i]) {
+			return e, fmt.Errorf("the replay refused %v, but the record says %v", result.RuleViolations[i].Err, record.IllegalMoves[i].Err)
+		}
+	}
+	if !reflect.DeepEqual(scores, record.Scores) && !(len(scores) == 0 && len(record.Scores) == 0) {
+		return e, fmt.Errorf("the replay scored %v, but the record says %v", scores, record.Scores)
+	}
+	if !reflect.DeepEqual(winners, record.Winners) && !(len(winners) == 0 && len(record.Winners) == 0) {
+		return e, fmt.Errorf("the replay was won by %v, but the record says %v", winners, record.Winners)
+	}
+	return e, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const NUMSTATIONS = 3                             //under the Europe rules, every player has this many stations
+const STATIONSCORE = 4                            //the points for every station a player didn't build
+const NUMTUNNELCARDS = 3                          //the cards turned over from the deck when a tunnel is claimed
+const NUMINITIALLONGDESTINATIONTICKETSOFFERED = 1 //under the Europe rules, every player is offered one long ticket along with the first short ones
+const EUROPENUMSTARTINGTRAINS = 45
+
+//Ruleset is the edition of the rules a board is played with
+type Ruleset int
+
+const (
+	USARules    Ruleset = iota //the original game
+	EuropeRules                //stations, and a long destination ticket for every player at the start; tunnels and ferries are up to the map
+)
+
+var rulesetNames = []string{"usa", "europe"}
+
+func (r Ruleset) String() string {
+	if r < 0 || int(r) >= len(rulesetNames) {
+		return "unknown rules " + strconv.Itoa(int(r))
+	}
+	return rulesetNames[r]
+}
+
+//parseRuleset reads the rules of a map file, where no rules at all are the original game's
+func parseRuleset(name string) (Ruleset, error) {
+	if name == "" {
+		return USARules, nil
+	}
+	for i, rulesName := range rulesetNames {
+		if rulesName == name {
+			return Ruleset(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rules %q, expected one of %v", name, rulesetNames)
+}
+
+//gameConstants returns the constants of the standard game under these rules
+func (r Ruleset) gameConstants() GameConstants {
+	c := defaultGameConstants()
+	if r == EuropeRules {
+		c.NumStartingTrains = EUROPENUMSTARTINGTRAINS
+		c.NumStations = NUMSTATIONS
+		c.StationScore = STATIONSCORE
+		c.NumInitialLongDestinationTicketsOffered = NUMINITIALLONGDESTINATIONTICKETSOFFERED
+	}
+	return c
+}
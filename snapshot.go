@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"path/filepath"
+)
+
+const SNAPSHOTVERSION = 1
+
+//countingSource is a random source that counts how many numbers it has produced, so that its state can be saved as a seed and a count
+type countingSource struct {
+	source rand.Source64
+	seed   int64
+	draws  uint64
+}
+
+//newCountingSource returns the source seeded with seed, after it has produced draws numbers
+func newCountingSource(seed int64, draws uint64) *countingSource {
+	s := &countingSource{source: rand.NewSource(seed).(rand.Source64), seed: seed}
+	for s.draws < draws {
+		s.Uint64()
+	}
+	return s
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.source.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.source.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.source.Seed(seed)
+	s.seed = seed
+	s.draws = 0
+}
+
+//TicketSnapshot is a DestinationTicket with exported fields, so that it can be encoded
+type TicketSnapshot struct {
+	From, To Destination
+	Points   int
+}
+
+func snapshotTickets(tickets []DestinationTicket) []TicketSnapshot {
+	snapshots := make([]TicketSnapshot, len(tickets))
+	for i, ticket := range tickets {
+		snapshots[i] = TicketSnapshot{From: ticket.d1, To: ticket.d2, Points: ticket.points}
+	}
+	return snapshots
+}
+
+func restoreTickets(snapshots []TicketSnapshot) []DestinationTicket {
+	tickets := make([]DestinationTicket, len(snapshots))
+	for i, snapshot := range snapshots {
+		tickets[i] = DestinationTicket{d1: snapshot.From, d2: snapshot.To, points: snapshot.Points}
+	}
+	return tickets
+}
+
+//GameSnapshot is everything the engine knows about a game between two turns
+//the players are not part of it: a game is resumed with a fresh set of players, who are told what they need to know
+type GameSnapshot struct {
+	Version int
+	Map     string //the board, as loadBoard knows it
+
+	Constants         GameConstants
+	RouteLengthScores []int //GameConstants.routeLengthScores, which isn't exported
+	IllegalMovePolicy IllegalMovePolicy
+	KeepTies          bool
+
+	Seed     int64
+	RNGDraws uint64 //how many numbers the engine's random source had produced: together with Seed, this is its state
+
+	ActivePlayer          int
+	NumTurns              int
+	FalseMoveCount        int
+	FinalRoundTriggeredBy int
+	TurnsLeftInFinalRound int
+
+	TrainCardHands         [][]int
+	DestinationTicketHands [][]TicketSnapshot
+	NumTrains              []int
+	TrackStatus            []int
+	FaceUpTrainCards       []int
+	Stations               []int `json:",omitempty"` //who built a station in each city, or -1 if nobody has
+	StationsLeft           []int `json:",omitempty"`
+
+	PileOfTrainCards         []GameColor //the deck, top card last
+	DiscardPileOfTrainCards  []GameColor
+	PileOfDestinationTickets []TicketSnapshot //the ticket pile, top ticket first
+	PileOfLongTickets        []TicketSnapshot `json:",omitempty"`
+
+	RuleViolations []RuleViolation
+	Disqualified   []bool
+	Aborted        bool
+}
+
+//snapshot captures the state of the game; it should be taken between turns, for example when a TurnStarted event is observed
+func (e *Engine) snapshot() GameSnapshot {
+	s := GameSnapshot{
+		Version:                  SNAPSHOTVERSION,
+		Map:                      e.board().source,
+		Constants:                e.gameConstants,
+		RouteLengthScores:        append([]int(nil), e.gameConstants.routeLengthScores...),
+		IllegalMovePolicy:        e.IllegalMovePolicy,
+		KeepTies:                 e.KeepTies,
+		Seed:                     e.Seed,
+		RNGDraws:                 e.rngSource.draws,
+		ActivePlayer:             e.activePlayer,
+		NumTurns:                 e.numTurns,
+		FalseMoveCount:           e.falseMoveCount,
+		FinalRoundTriggeredBy:    e.finalRoundTriggeredBy,
+		TurnsLeftInFinalRound:    e.turnsLeftInFinalRound,
+		NumTrains:                append([]int(nil), e.numTrains...),
+		TrackStatus:              append([]int(nil), e.trackStatus...),
+		FaceUpTrainCards:         append([]int(nil), e.faceUpTrainCards...),
+		Stations:                 append([]int(nil), e.stations...),
+		StationsLeft:             append([]int(nil), e.stationsLeft...),
+		PileOfTrainCards:         append([]GameColor(nil), e.pileOfTrainCards...),
+		DiscardPileOfTrainCards:  append([]GameColor(nil), e.discardPileOfTrainCards...),
+		PileOfDestinationTickets: snapshotTickets(e.pileOfDestinationTickets),
+		PileOfLongTickets:        snapshotTickets(e.pileOfLongDestinationTickets),
+		RuleViolations:           append([]RuleViolation(nil), e.ruleViolations...),
+		Disqualified:             append([]bool(nil), e.disqualified...),
+		Aborted:                  e.aborted,
+	}
+
+	for i := range e.playerList {
+		s.TrainCardHands = append(s.TrainCardHands, append([]int(nil), e.trainCardHands[i]...))
+		s.DestinationTicketHands = append(s.DestinationTicketHands, snapshotTickets(e.destinationTicketHands[i]))
+	}
+	return s
+}
+
+//restoreSnapshot puts the engine in the state of the snapshot, and brings the fresh players up to date
+func (e *Engine) restoreSnapshot(s GameSnapshot, playerList []Player) error {
+	if s.Version != SNAPSHOTVERSION {
+		return fmt.Errorf("snapshot version %d is not supported, expected %d", s.Version, SNAPSHOTVERSION)
+	}
+	if len(playerList) != s.Constants.NumPlayers {
+		return fmt.Errorf("the snapshot is of a game with %d players, but %d players were given", s.Constants.NumPlayers, len(playerList))
+	}
+	if len(s.TrainCardHands) != len(playerList) || len(s.DestinationTicketHands) != len(playerList) || len(s.NumTrains) != len(playerList) || len(s.Disqualified) != len(playerList) {
+		return fmt.Errorf("the snapshot doesn't hold the hands, trains and disqualifications of all %d players", len(playerList))
+	}
+
+	e.playerList = playerList
+	e.gameConstants = s.Constants
+	e.gameConstants.routeLengthScores = append([]int(nil), s.RouteLengthScores...)
+	e.IllegalMovePolicy = s.IllegalMovePolicy
+	e.KeepTies = s.KeepTies
+
+	e.Seed = s.Seed
+	e.rngSource = newCountingSource(s.Seed, s.RNGDraws)
+	e.rng = rand.New(e.rngSource)
+
+	board, err := loadBoard(s.Map)
+	if err != nil {
+		return fmt.Errorf("the snapshot is of a game on map %q: %v", s.Map, err)
+	}
+	e.Board = board
+	e.destinationNames = append([]string(nil), board.DestinationNames...)
+	e.stringColors = append([]string(nil), stringColors...)
+	e.trackList = append([]Track(nil), board.Tracks...)
+	if len(s.TrackStatus) != len(e.trackList) {
+		return fmt.Errorf("the snapshot has %d tracks, but the board has %d", len(s.TrackStatus), len(e.trackList))
+	}
+	e.populateAdjacencyList()
+	e.populateDoubleRouteSiblings()
+
+	e.activePlayer = s.ActivePlayer
+	e.numTurns = s.NumTurns
+	e.falseMoveCount = s.FalseMoveCount
+	e.finalRoundTriggeredBy = s.FinalRoundTriggeredBy
+	e.turnsLeftInFinalRound = s.TurnsLeftInFinalRound
+
+	e.trainCardHands = nil
+	e.destinationTicketHands = nil
+	for i := range playerList {
+		e.trainCardHands = append(e.trainCardHands, append([]int(nil), s.TrainCardHands[i]...))
+		e.destinationTicketHands = append(e.destinationTicketHands, restoreTickets(s.DestinationTicketHands[i]))
+	}
+	e.numTrains = append([]int(nil), s.NumTrains...)
+	e.trackStatus = append([]int(nil), s.TrackStatus...)
+	e.faceUpTrainCards = append([]int(nil), s.FaceUpTrainCards...)
+	e.pileOfTrainCards = append([]GameColor(nil), s.PileOfTrainCards...)
+	e.discardPileOfTrainCards = append([]GameColor(nil), s.DiscardPileOfTrainCards...)
+	e.pileOfDestinationTickets = restoreTickets(s.PileOfDestinationTickets)
+	e.pileOfLongDestinationTickets = restoreTickets(s.PileOfLongTickets)
+
+	e.stations = append([]int(nil), s.Stations...)
+	if len(e.stations) == 0 {
+		//	snapshots from before there were stations
+		e.stations = noStations(len(e.destinationNames))
+	}
+	e.stationsLeft = append([]int(nil), s.StationsLeft...)
+	if len(e.stationsLeft) == 0 {
+		e.stationsLeft = make([]int, len(playerList))
+	}
+	if len(e.stations) != len(e.destinationNames) || len(e.stationsLeft) != len(playerList) {
+		return fmt.Errorf("the snapshot doesn't hold the stations of all %d cities and %d players", len(e.destinationNames), len(playerList))
+	}
+
+	e.ruleViolations = append([]RuleViolation(nil), s.RuleViolations...)
+	e.disqualified = append([]bool(nil), s.Disqualified...)
+	e.aborted = s.Aborted
+
+	e.emit(GameStarted{Seed: e.Seed, NumPlayers: len(e.playerList), DestinationNames: e.destinationNames})
+
+	for i, p := range e.playerList {
+		//	the players' random sources come from the engine's, as they do in a new game
+		e.initializePlayer(i, p)
+	}
+	//	giving the players their sources drew from the engine's, which a new game did before the snapshot was taken: put it back where the snapshot left it
+	e.rngSource = newCountingSource(s.Seed, s.RNGDraws)
+	e.rng = rand.New(e.rngSource)
+
+	//	tell every player what they would have seen: their own cards and tickets, how many the others hold, and the tracks laid so far
+	for i, p := range e.playerList {
+		for c, howMany := range e.trainCardHands[i] {
+			for j := 0; j < howMany; j++ {
+				p.giveTrainCard(GameColor(c))
+				for k, pl := range e.playerList {
+					if k == i {
+						pl.informCardPickup(i, GameColor(c))
+					} else {
+						pl.informCardPickup(i, Other)
+					}
+				}
+			}
+		}
+		for _, ticket := range e.destinationTicketHands[i] {
+			p.giveDestinationTicket(ticket)
+			for _, pl := range e.playerList {
+				pl.informDestinationTicketPickup(i)
+			}
+		}
+	}
+	for track, owner := range e.trackStatus {
+		if owner >= 0 {
+			for _, pl := range e.playerList {
+				pl.informTrackLay(owner, track)
+			}
+		}
+	}
+	for _, pl := range e.playerList {
+		if e.finalRoundTriggeredBy != -1 {
+			pl.informFinalRound(e.finalRoundTriggeredBy)
+		}
+		pl.informGameResumed(append([]int(nil), e.numTrains...))
+	}
+	return nil
+}
+
+//resumeGame plays a game from a snapshot to the end with a fresh set of players, and returns how it ended like runGame
+func (e *Engine) resumeGame(s GameSnapshot, playerList []Player) (GameResult, error) {
+	//	if anything blows up, make sure we know which seed to replay
+	defer func() {
+		if r := recover(); r != nil {
+			panic(fmt.Sprintf("the game with seed %d, resumed from a snapshot, crashed: %v", e.Seed, r))
+		}
+	}()
+
+	if err := e.restoreSnapshot(s, playerList); err != nil {
+		return GameResult{}, err
+	}
+	return e.playUntilGameOver(), nil
+}
+
+//SnapshotFormat is how a snapshot is encoded
+type SnapshotFormat int
+
+const (
+	JSONSnapshot SnapshotFormat = iota //readable, for debugging
+	GobSnapshot                        //compact binary encoding
+)
+
+//snapshotFormatForFile picks gob for .gob files, and JSON otherwise
+func snapshotFormatForFile(filename string) SnapshotFormat {
+	if filepath.Ext(filename) == ".gob" {
+		return GobSnapshot
+	}
+	return JSONSnapshot
+}
+
+func writeSnapshot(w io.Writer, s GameSnapshot, format SnapshotFormat) error {
+	if format == GobSnapshot {
+		return gob.NewEncoder(w).Encode(s)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(s)
+}
+
+func readSnapshot(r io.Reader, format SnapshotFormat) (GameSnapshot, error) {
+	var s GameSnapshot
+	var err error
+	if format == GobSnapshot {
+		err = gob.NewDecoder(r).Decode(&s)
+	} else {
+		err = json.NewDecoder(r).Decode(&s)
+	}
+	return s, err
+}
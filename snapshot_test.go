@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+//snapshotTaker keeps a snapshot of the game at the start of one turn
+type snapshotTaker struct {
+	engine   *Engine
+	turn     int
+	taken    bool
+	snapshot GameSnapshot
+}
+
+func (s *snapshotTaker) observe(event GameEvent) {
+	if _, ok := event.(TurnStarted); ok && s.engine.numTurns == s.turn {
+		s.snapshot = s.engine.snapshot()
+		s.taken = true
+	}
+}
+
+//zebras only: they don't use their random sources, so fresh ones pick up a resumed game exactly where the old ones left it
+func newSnapshotTestEngine(seed int64) (*Engine, []Player) {
+	e := Engine{}
+	e.OptimizerMode = true
+	e.IllegalMovePolicy = ForfeitTurnOnIllegalMove
+	e.Board = usaBoard
+	e.Seed = seed
+	return &e, []Player{&ZebraBot{}, &ZebraBot{}, &ZebraBot{}, &ZebraBot{}}
+}
+
+//a game resumed from a snapshot, saved as gob or JSON, must end the way the game it was taken from did
+func TestResumedGameMatchesOriginal(t *testing.T) {
+	seeds := 10
+	if testing.Short() {
+		seeds = 3
+	}
+
+	for seed := int64(1); seed <= int64(seeds); seed++ {
+		for _, turn := range []int{5, 40, 80} {
+			e, players := newSnapshotTestEngine(seed)
+			taker := &snapshotTaker{engine: e, turn: turn}
+			e.addObserver(taker)
+			original := e.runGame(players, usaBoard.gameConstants())
+			if !taker.taken {
+				//	the game ended before that turn
+				continue
+			}
+
+			for _, format := range []SnapshotFormat{GobSnapshot, JSONSnapshot} {
+				var buffer bytes.Buffer
+				if err := writeSnapshot(&buffer, taker.snapshot, format); err != nil {
+					t.Fatal(err)
+				}
+				snapshot, err := readSnapshot(&buffer, format)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				resumed, fresh := newSnapshotTestEngine(seed)
+				result, err := resumed.resumeGame(snapshot, fresh)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(original, result) {
+					t.Errorf("seed %d resumed at turn %d from format %d ended differently:\n%+v\n%+v", seed, turn, format, result, original)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+//seatTotals adds up how the player in one seat did over many games
+type seatTotals struct {
+	games            int
+	score            int
+	routePoints      int
+	ticketPoints     int
+	longestPathBonus int
+	stationPoints    int
+	globetrotter     int
+	tickets          int
+	ticketsCompleted int
+	trainsLeft       int
+	timeouts         int
+	abandoned        int
+}
+
+func (t *seatTotals) add(player PlayerResult) {
+	t.games++
+	t.score += player.Score
+	t.routePoints += player.RoutePoints
+	t.ticketPoints += player.TicketPoints
+	t.longestPathBonus += player.LongestPathBonus
+	t.stationPoints += player.StationPoints
+	t.globetrotter += player.GlobetrotterBonus
+	t.tickets += len(player.Tickets)
+	t.ticketsCompleted += player.TicketsCompleted()
+	t.trainsLeft += player.TrainsLeft
+	if player.Timing.TimedOut {
+		t.timeouts++
+	}
+	t.abandoned += player.Timing.Abandoned
+}
+
+func (t *seatTotals) average(total int) float64 {
+	return float64(total) / float64(t.games)
+}
+
+//printSummary prints why the games ended, how long they took, and how every seat did on average in the games that were scored
+func printSummary(results []GameResult) {
+	endReasons := make(map[string]int)
+	turns := 0
+	var seats []seatTotals
+	for _, result := range results {
+		endReasons[result.EndReason.String()]++
+		turns += result.NumTurns
+		for i, player := range result.Players {
+			if i >= len(seats) {
+				seats = append(seats, seatTotals{})
+			}
+			seats[i].add(player)
+		}
+	}
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Println("Games ended:", endReasons)
+	fmt.Printf("Turns per game: %.1f\n", float64(turns)/float64(len(results)))
+	for i, seat := range seats {
+		ticketsCompleted := 0.0
+		if seat.tickets > 0 {
+			ticketsCompleted = 100 * float64(seat.ticketsCompleted) / float64(seat.tickets)
+		}
+		extras := ""
+		if seat.stationPoints > 0 {
+			extras = fmt.Sprintf(", stations %.1f", seat.average(seat.stationPoints))
+		}
+		if seat.globetrotter > 0 {
+			extras += fmt.Sprintf(", most tickets %.1f", seat.average(seat.globetrotter))
+		}
+		fmt.Printf("Seat %d: %.1f points (routes %.1f, tickets %.1f%s, longest path %.1f), %.0f%% of tickets completed, %.1f trains left",
+			i, seat.average(seat.score), seat.average(seat.routePoints), seat.average(seat.ticketPoints), extras, seat.average(seat.longestPathBonus), ticketsCompleted, seat.average(seat.trainsLeft))
+		if seat.timeouts > 0 {
+			fmt.Printf(", out of time in %d games", seat.timeouts)
+		}
+		if seat.abandoned > 0 {
+			fmt.Printf(", %d calls abandoned", seat.abandoned)
+		}
+		fmt.Println()
+	}
+	if running := atomic.LoadInt64(&abandonedCallsRunning); running > 0 {
+		fmt.Printf("%d abandoned calls are still running\n", running)
+	}
+}
@@ -9,8 +9,13 @@ type DestinationTicket struct {
 }
 
 type GameConstants struct {
-	NumDestinations, NumTracks, NumColorCards, NumRainbowCards, NumStartingTrains, NumFaceUpTrainCards, NumGameColors, NumInitialTrainCardsDealt, NumInitialDestinationTicketsOffered, NumInitialDestinationTicketsPicked, NumDestinationTicketsOffered, NumDestinationTicketsPicked, NumPlayers, LongestPathScore int
-	routeLengthScores                                                                                                                                                                                                                                                                                              []int
+	NumDestinations, NumTracks, NumColorCards, NumRainbowCards, NumStartingTrains, NumTrainsForFinalRound, NumFaceUpTrainCards, NumFaceUpRainbowsForReshuffle, NumGameColors, NumInitialTrainCardsDealt, NumInitialDestinationTicketsOffered, NumInitialDestinationTicketsPicked, NumDestinationTicketsOffered, NumDestinationTicketsPicked, NumPlayers, LongestPathScore, DoubleRouteMinPlayers, MinPlayers, MaxPlayers, NumDestinationTickets int
+	NumStations, StationScore, NumTunnelCards, NumInitialLongDestinationTicketsOffered, NumLongDestinationTickets int //the Europe rules: stations per player and the points for each one left, the cards drawn for a tunnel, and the long tickets
+	Variant           Variant //the variant played, which picks the ticket deck
+	GlobetrotterScore int     //the points for completing the most destination tickets
+	DestinationNames []string //the names of the board's cities, indexed by Destination
+	PlayerCountRules                                                                                                                                                                                                                                                                                                                                                                                                     []PlayerCountRule //changes to the constants for some numbers of players, applied in order
+	routeLengthScores                                                                                                                                                                                                                                                                                                                                                                                                    []int
 }
 
 type Track struct {
@@ -18,4 +23,7 @@ type Track struct {
 	d1, d2 Destination // two endpoints
 	c      GameColor   //what color
 	length int         // What is the length of the road
+
+	kind        RouteType //the rules the track is claimed under
+	locomotives int       //for ferries: how many of the cards paying for the track must be rainbows
 }
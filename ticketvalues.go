@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const DEFAULTTICKETLENGTHS = "4-8:30,9-13:45,14-22:25" //about how the lengths of the original game's tickets are spread
+
+//shortestTrainLengths returns how many trains the shortest way from a city to every other city over the board's tracks takes, or -1 for the cities it can't reach
+func shortestTrainLengths(b *Board, from Destination) []int {
+	distance := make([]int, len(b.DestinationNames))
+	for d := range distance {
+		distance[d] = -1
+	}
+	adjacent := make([][]Track, len(b.DestinationNames))
+	for _, t := range b.Tracks {
+		adjacent[t.d1] = append(adjacent[t.d1], t)
+		adjacent[t.d2] = append(adjacent[t.d2], t)
+	}
+
+	//	boards have a few dozen cities, so the closest city not yet done is simply searched for
+	done := make([]bool, len(distance))
+	distance[from] = 0
+	for {
+		closest := Destination(-1)
+		for d, dist := range distance {
+			if dist >= 0 && !done[d] && (closest == -1 || dist < distance[closest]) {
+				closest = Destination(d)
+			}
+		}
+		if closest == -1 {
+			return distance
+		}
+		done[closest] = true
+		for _, t := range adjacent[closest] {
+			next := otherEnd(t, closest)
+			if distance[next] == -1 || distance[closest]+t.length < distance[next] {
+				distance[next] = distance[closest] + t.length
+			}
+		}
+	}
+}
+
+//ticketValue is what a ticket is worth for the trains it takes at the least: the tickets of the original game are mostly worth exactly that
+func ticketValue(trains int) int {
+	return trains
+}
+
+//checkTicketValues prints every ticket of the board with the value the tracks give it, marking the ones whose printed value is different, and returns how many those are
+func checkTicketValues(b *Board) int {
+	disagree, total := 0, 0
+	for _, deck := range b.ticketDecks() {
+		for i, ticket := range deck.tickets {
+			total++
+			trains := shortestTrainLengths(b, ticket.d1)[ticket.d2]
+			mark := ""
+			if trains < 0 {
+				mark = " <- can't be completed"
+				disagree++
+			} else if value := ticketValue(trains); value != ticket.points {
+				mark = fmt.Sprintf(" <- the tracks make it worth %d", value)
+				disagree++
+			}
+			fmt.Printf("%s %d: %s to %s is worth %d, and takes %d trains%s\n", deck.name, i, b.DestinationNames[ticket.d1], b.DestinationNames[ticket.d2], ticket.points, trains, mark)
+		}
+	}
+	fmt.Printf("%d of the %d tickets of the %s board aren't worth what their tracks make them\n", disagree, total, b.Name)
+	return disagree
+}
+
+//ticketLengths is a share of a generated ticket deck: tickets that take between min and max trains, weighted against the other shares
+type ticketLengths struct {
+	min, max, weight int
+}
+
+//parseTicketLengths reads a length distribution like 4-8:30,9-13:45,14-22:25, where each share of the deck is a range of train lengths and a weight
+func parseTicketLengths(spec string) ([]ticketLengths, error) {
+	distribution := make([]ticketLengths, 0)
+	for _, share := range strings.Split(spec, ",") {
+		var l ticketLengths
+		parts := strings.Split(share, ":")
+		lengths := strings.Split(parts[0], "-")
+		if len(parts) != 2 || len(lengths) > 2 {
+			return nil, fmt.Errorf("%q isn't a range of train lengths and a weight, like 4-8:30", share)
+		}
+		var errs [3]error
+		l.min, errs[0] = strconv.Atoi(strings.TrimSpace(lengths[0]))
+		l.max, errs[1] = strconv.Atoi(strings.TrimSpace(lengths[len(lengths)-1]))
+		l.weight, errs[2] = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if errs[0] != nil || errs[1] != nil || errs[2] != nil || l.min < 1 || l.max < l.min || l.weight < 0 {
+			return nil, fmt.Errorf("%q isn't a range of train lengths and a weight, like 4-8:30", share)
+		}
+		distribution = append(distribution, l)
+	}
+	return distribution, nil
+}
+
+//shareOut splits count tickets between the shares of a distribution by their weights, rounding so that the counts add up
+func shareOut(count int, distribution []ticketLengths) []int {
+	totalWeight := 0
+	for _, l := range distribution {
+		totalWeight += l.weight
+	}
+	counts := make([]int, len(distribution))
+	if totalWeight == 0 {
+		return counts
+	}
+	given, cumulativeWeight := 0, 0
+	for i, l := range distribution {
+		cumulativeWeight += l.weight
+		counts[i] = count*cumulativeWeight/totalWeight - given
+		given += counts[i]
+	}
+	return counts
+}
+
+//generateTickets deals out a deck of count tickets for a board, with the train lengths spread out as the distribution says, each worth its ticketValue
+//it is balanced: no two tickets join the same cities, and every ticket is picked among those of its length to go to the cities the deck goes to least so far
+func generateTickets(b *Board, count int, distribution []ticketLengths, rng *rand.Rand) ([]DestinationTicket, error) {
+	type candidate struct {
+		ticket DestinationTicket
+		share  int
+	}
+	candidates := make([]candidate, 0)
+	for d1 := range b.DestinationNames {
+		trains := shortestTrainLengths(b, Destination(d1))
+		for d2 := d1 + 1; d2 < len(trains); d2++ {
+			for share, l := range distribution {
+				if trains[d2] >= l.min && trains[d2] <= l.max {
+					candidates = append(candidates, candidate{DestinationTicket{Destination(d1), Destination(d2), ticketValue(trains[d2])}, share})
+					break
+				}
+			}
+		}
+	}
+	rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	tickets := make([]DestinationTicket, 0, count)
+	used := make([]bool, len(candidates))
+	visits := make([]int, len(b.DestinationNames)) //how many tickets of the deck go to each city
+	counts := shareOut(count, distribution)
+	for share, n := range counts {
+		for ; n > 0; n-- {
+			best := -1
+			for i, c := range candidates {
+				if used[i] || c.share != share {
+					continue
+				}
+				if best == -1 || visits[c.ticket.d1]+visits[c.ticket.d2] < visits[candidates[best].ticket.d1]+visits[candidates[best].ticket.d2] {
+					best = i
+				}
+			}
+			if best == -1 {
+				l := distribution[share]
+				return nil, fmt.Errorf("the %s board hasn't %d pairs of cities %d to %d trains apart", b.Name, counts[share], l.min, l.max)
+			}
+			used[best] = true
+			ticket := candidates[best].ticket
+			visits[ticket.d1]++
+			visits[ticket.d2]++
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		if tickets[i].d1 != tickets[j].d1 {
+			return tickets[i].d1 < tickets[j].d1
+		}
+		return tickets[i].d2 < tickets[j].d2
+	})
+	return tickets, nil
+}
+
+//printTickets prints tickets as the Tickets of a map file, one ticket to a line as in the maps directory
+func printTickets(b *Board, tickets []DestinationTicket) error {
+	lines := make([]string, len(tickets))
+	for i, ticket := range tickets {
+		line, err := json.Marshal(MapTicket{From: b.DestinationNames[ticket.d1], To: b.DestinationNames[ticket.d2], Points: ticket.points})
+		if err != nil {
+			return err
+		}
+		lines[i] = "\t\t" + strings.NewReplacer(`":`, `": `, `,"`, `, "`).Replace(string(line))
+	}
+	fmt.Printf("\t\"Tickets\": [\n%s\n\t],\n", strings.Join(lines, ",\n"))
+	return nil
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+//TimeoutPolicy decides how a player that ran out of time plays the rest of its game
+type TimeoutPolicy int
+
+const (
+	FallbackOnTimeout TimeoutPolicy = iota //a safe legal answer is given for the player: it draws cards whenever it can, keeps the first tickets it is offered, and gives up tunnels that cost more cards
+	ForfeitOnTimeout                       //every question gets an illegal answer, and the illegal move policy decides what that costs
+)
+
+var timeoutPolicyNames = []string{"fallback", "forfeit"}
+
+func (p TimeoutPolicy) String() string {
+	if p < 0 || int(p) >= len(timeoutPolicyNames) {
+		return "unknown policy " + strconv.Itoa(int(p))
+	}
+	return timeoutPolicyNames[p]
+}
+
+func parseTimeoutPolicy(name string) (TimeoutPolicy, error) {
+	for i, policyName := range timeoutPolicyNames {
+		if policyName == name {
+			return TimeoutPolicy(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown timeout policy %q, expected one of %v", name, timeoutPolicyNames)
+}
+
+//TimeLimits bound how long a player may take to play; a zero limit is no limit
+type TimeLimits struct {
+	PerCall time.Duration //how long a single call of the player may take, be it a question or news about the game
+	PerGame time.Duration //how long all the calls of the player in a game may take together
+	Policy  TimeoutPolicy //what happens once the player runs out of time
+}
+
+func (l TimeLimits) limited() bool {
+	return l.PerCall > 0 || l.PerGame > 0
+}
+
+//PlayerTiming is how long a player took to play a game
+type PlayerTiming struct {
+	Calls     int           //how many times the engine called the player
+	Total     time.Duration //the time spent in all of those calls
+	Longest   time.Duration //the longest call
+	TimedOut  bool          //whether the player ran out of time, after which it was never called again
+	Abandoned int           //how many calls ran out of time and were left running, since a goroutine can't be stopped
+}
+
+//abandonedCallsRunning counts the calls of every game that ran out of time and haven't returned yet
+//each one holds a goroutine, and a bot stuck in a loop a CPU as well, until the program ends
+var abandonedCallsRunning int64
+
+//contextUser is a player that can be told to give up a call that ran out of time
+//before every call the player is given a context, which is cancelled once the call is out of time or done
+type contextUser interface {
+	useContext(ctx context.Context)
+}
+
+//playerTimings returns how long each player of a game took, or nil if the players had no time limits
+func playerTimings(players []Player) []PlayerTiming {
+	var timings []PlayerTiming
+	for i, p := range players {
+		if recording, ok := p.(*recordingPlayer); ok {
+			p = recording.Player
+		}
+		timed, ok := p.(*timedPlayer)
+		if !ok {
+			continue
+		}
+		if timings == nil {
+			timings = make([]PlayerTiming, len(players))
+		}
+		timings[i] = timed.stats
+	}
+	return timings
+}
+
+//timedPlayer runs every call of a player on a goroutine of its own, and stops waiting for it once the player is out of time
+//a call that ran out of time can't be stopped, so the player is never called again: it may still be busy, and it misses what happens next
+//from then on the timeout policy answers for it
+//the goroutine of that call is abandoned: a contextUser is told to give up through its context, a bot program is killed when the game is over, and any other player runs until it returns by itself
+type timedPlayer struct {
+	Player
+	limits TimeLimits
+
+	myNumber int
+	view     PlayerView //the view of the last informStatus, which the fallback answers of askMove, askTrackLay and askStation are chosen from
+	stats    PlayerTiming
+}
+
+func newTimedPlayer(p Player, limits TimeLimits) *timedPlayer {
+	return &timedPlayer{Player: p, limits: limits}
+}
+
+//call runs f under the time limits, and says whether it finished in time
+//a panic in f is passed on to the goroutine running the game, so that the game's seed is reported
+func (t *timedPlayer) call(question string, f func()) bool {
+	if t.stats.TimedOut {
+		return false
+	}
+
+	limit := t.limits.PerCall
+	if t.limits.PerGame > 0 {
+		left := t.limits.PerGame - t.stats.Total
+		if left <= 0 {
+			t.timeOut(question, 0)
+			return false
+		}
+		if limit <= 0 || left < limit {
+			limit = left
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
+	defer cancel()
+	if user, ok := t.Player.(contextUser); ok {
+		user.useContext(ctx)
+	}
+
+	//	the state of the call: 0 while it runs, 1 once it returned, 2 once it was abandoned
+	var state int32
+	finished := make(chan interface{}, 1)
+	start := time.Now()
+	go func() {
+		defer func() {
+			if !atomic.CompareAndSwapInt32(&state, 0, 1) {
+				atomic.AddInt64(&abandonedCallsRunning, -1)
+			}
+			finished <- recover()
+		}()
+		f()
+	}()
+
+	select {
+	case panicked := <-finished:
+		if panicked != nil {
+			panic(panicked)
+		}
+		t.record(time.Since(start))
+		return true
+	case <-ctx.Done():
+		if atomic.CompareAndSwapInt32(&state, 0, 2) {
+			atomic.AddInt64(&abandonedCallsRunning, 1)
+		}
+		t.stats.Abandoned++
+		t.timeOut(question, limit)
+		return false
+	}
+}
+
+func (t *timedPlayer) record(took time.Duration) {
+	t.stats.Calls++
+	t.stats.Total += took
+	if took > t.stats.Longest {
+		t.stats.Longest = took
+	}
+}
+
+func (t *timedPlayer) timeOut(question string, limit time.Duration) {
+	t.record(limit)
+	t.stats.TimedOut = true
+	log.Printf("player %d ran out of time answering %s, so the %s policy plays for it from now on", t.myNumber, question, t.limits.Policy)
+}
+
+func (t *timedPlayer) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) {
+	t.myNumber = myNumber
+	t.call("initialize", func() {
+		t.Player.initialize(myNumber, trackList, adjList, constants, rng)
+	})
+}
+
+func (t *timedPlayer) informCardPickup(player int, color GameColor) {
+	t.call("informCardPickup", func() {
+		t.Player.informCardPickup(player, color)
+	})
+}
+
+func (t *timedPlayer) informTrackLay(player int, track int) {
+	t.call("informTrackLay", func() {
+		t.Player.informTrackLay(player, track)
+	})
+}
+
+func (t *timedPlayer) informDestinationTicketPickup(player int) {
+	t.call("informDestinationTicketPickup", func() {
+		t.Player.informDestinationTicketPickup(player)
+	})
+}
+
+func (t *timedPlayer) informFinalRound(player int) {
+	t.call("informFinalRound", func() {
+		t.Player.informFinalRound(player)
+	})
+}
+
+func (t *timedPlayer) informGameResumed(numTrains []int) {
+	t.call("informGameResumed", func() {
+		t.Player.informGameResumed(numTrains)
+	})
+}
+
+func (t *timedPlayer) informStatus(view PlayerView) {
+	t.view = view
+	t.call("informStatus", func() {
+		t.Player.informStatus(view)
+	})
+}
+
+func (t *timedPlayer) giveTrainCard(color GameColor) {
+	t.call("giveTrainCard", func() {
+		t.Player.giveTrainCard(color)
+	})
+}
+
+func (t *timedPlayer) giveDestinationTicket(ticket DestinationTicket) {
+	t.call("giveDestinationTicket", func() {
+		t.Player.giveDestinationTicket(ticket)
+	})
+}
+
+func (t *timedPlayer) askMove() int {
+	var move int
+	if t.call("askMove", func() {
+		move = t.Player.askMove()
+	}) {
+		return move
+	}
+
+	if t.limits.Policy == ForfeitOnTimeout {
+		return -1
+	}
+	moves := LegalMoves(t.view).Moves
+	if len(moves) == 0 || itemExists(moves, 0) {
+		return 0
+	}
+	return moves[0]
+}
+
+func (t *timedPlayer) askPickup(howManyLeft int, view PlayerView) GameColor {
+	var color GameColor
+	if t.call("askPickup", func() {
+		color = t.Player.askPickup(howManyLeft, view)
+	}) {
+		return color
+	}
+
+	if t.limits.Policy == ForfeitOnTimeout {
+		return -1
+	}
+	pickups := legalPickups(view, howManyLeft)
+	if len(pickups) == 0 || itemExists(pickups, Other) {
+		return Other
+	}
+	return pickups[0]
+}
+
+func (t *timedPlayer) askTrackLay() (int, Payment) {
+	var track int
+	var payment Payment
+	if t.call("askTrackLay", func() {
+		track, payment = t.Player.askTrackLay()
+	}) {
+		return track, payment
+	}
+
+	claimable := claimableTracks(t.view)
+	if t.limits.Policy == ForfeitOnTimeout || len(claimable) == 0 {
+		return -1, Payment{Color: Other}
+	}
+	return claimable[0].Track, claimable[0].Payments[0]
+}
+
+func (t *timedPlayer) askStation() (Destination, Payment) {
+	var city Destination
+	var payment Payment
+	if t.call("askStation", func() {
+		city, payment = t.Player.askStation()
+	}) {
+		return city, payment
+	}
+
+	cities := buildableStations(t.view)
+	if t.limits.Policy == ForfeitOnTimeout || len(cities) == 0 {
+		return -1, Payment{Color: Other}
+	}
+	return cities[0], stationPayments(t.view)[0]
+}
+
+func (t *timedPlayer) askTunnelPayment(track int, drawn []GameColor, extra Payment) bool {
+	var pay bool
+	if t.call("askTunnelPayment", func() {
+		pay = t.Player.askTunnelPayment(track, drawn, extra)
+	}) {
+		return pay
+	}
+
+	//	giving up the claim keeps the player's cards, and is always legal
+	return false
+}
+
+func (t *timedPlayer) offerDestinationTickets(tickets []DestinationTicket, minKept int) []int {
+	var kept []int
+	if t.call("offerDestinationTickets", func() {
+		kept = t.Player.offerDestinationTickets(tickets, minKept)
+	}) {
+		return kept
+	}
+
+	if t.limits.Policy == ForfeitOnTimeout {
+		return nil
+	}
+	first := make([]int, 0)
+	for i := 0; i < minKept && i < len(tickets); i++ {
+		first = append(first, i)
+	}
+	return first
+}
+
+//Close stops the player if it is a bot program
+//a bot that ran out of time may still be busy with its last call, so its program is killed instead of being told the game is over
+func (t *timedPlayer) Close() error {
+	if bot, ok := t.Player.(*processPlayer); ok && t.stats.TimedOut {
+		bot.kill()
+		return nil
+	}
+	if closer, ok := t.Player.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
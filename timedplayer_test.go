@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//spinningPlayer loops in askMove until its context is cancelled
+type spinningPlayer struct {
+	ZebraBot
+	ctx context.Context
+}
+
+func (s *spinningPlayer) useContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+func (s *spinningPlayer) askMove() int {
+	for s.ctx.Err() == nil {
+	}
+	return 0
+}
+
+//a call that runs out of time is abandoned, counted, and told through its context to give up
+func TestTimedOutCallIsCancelled(t *testing.T) {
+	timed := newTimedPlayer(&spinningPlayer{}, TimeLimits{PerCall: 10 * time.Millisecond})
+	timed.askMove()
+	if !timed.stats.TimedOut || timed.stats.Abandoned != 1 {
+		t.Fatalf("the call that ran out of time gave the timing %+v", timed.stats)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&abandonedCallsRunning) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the abandoned call is still running after its context was cancelled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,114 @@
+//Package ttr is the API for writing Ticket To Ride bots outside of the engine's own package
+//a bot implements Player and registers itself under a name, usually from an init function; the engine can then seat it at a game by that name
+package ttr
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+//APIVersion is the version of the Player interface in this package; it goes up whenever the interface changes
+const APIVersion = 1
+
+//Move is a player's choice of what to do with their turn
+type Move int
+
+const (
+	DrawTrainCards Move = iota
+	ClaimTrack
+	DrawDestinationTickets
+	BuildStation //under the Europe rules; only a StationBuilder should choose it
+)
+
+//Player is a bot. The engine calls it from one goroutine at a time
+type Player interface {
+	//APIVersion is the version of this package the bot was written against; the engine refuses to seat a bot of another version
+	APIVersion() int
+
+	Initialize(myNumber int, tracks []Track, adjacency [][]int, rules Rules, rng *rand.Rand) //called once, before the game; adjacency lists the tracks at each destination
+
+	InformCardPickup(player int, color GameColor) //a player picked up a card: Other if it came from the deck, unless it was this player
+	InformTrackLay(player int, track int)         //a player claimed a track
+	InformDestinationTicketPickup(player int)     //a player kept a destination ticket
+	InformFinalRound(player int)                  //a player dropped to few enough trains to start the final round: everybody, including that player, gets exactly one more turn
+	InformGameResumed(numTrains []int)            //the game was resumed from a snapshot, after this player was told its cards, tickets and the tracks laid so far
+	InformStatus(view PlayerView)                 //called before every turn of this player
+
+	AskMove() Move
+	AskPickup(howManyLeft int, view PlayerView) GameColor //which face up card to pick up, or Other for the top of the deck
+	AskTrackLay() (track int, color GameColor)            //which track to claim, and which color to pay with
+
+	GiveTrainCard(color GameColor)
+	GiveDestinationTicket(ticket DestinationTicket)
+	OfferDestinationTickets(tickets []DestinationTicket, minKept int) []int //returns the indices of the tickets kept
+}
+
+//StationBuilder is a Player that builds stations, under the Europe rules
+type StationBuilder interface {
+	AskStation() (city Destination, color GameColor) //where to build a station, and which color to pay with
+}
+
+//SplitPayer is a Player that chooses how it pays for tracks: how many of the cards are rainbows, to keep cards of the color
+//the engine asks it instead of AskTrackLay. A Player that isn't one pays with as many cards of its color as it holds, and rainbows for the rest
+type SplitPayer interface {
+	AskTrackLayPayment() (track int, payment Payment)
+}
+
+//SplitStationBuilder is a StationBuilder that chooses how it pays for stations, like a SplitPayer; the engine asks it instead of AskStation
+type SplitStationBuilder interface {
+	AskStationPayment() (city Destination, payment Payment)
+}
+
+//TunnelPayer is a Player that decides whether to pay the extra cards a tunnel calls for; a Player that isn't one always pays them
+type TunnelPayer interface {
+	AskTunnelPayment(track int, drawn []GameColor, extra Payment) bool //drawn are the cards turned over, and extra the cards they call for; not paying gives up the claim
+}
+
+//ContextUser is a Player that can give up a call once it runs out of time, under the engine's time limits
+//before every call the engine hands it a context, which is cancelled once the call is out of time or done; a bot that searches for long should check it and return
+//the engine neither waits for nor uses an answer given after the context is cancelled, and a Player that isn't one keeps running until it returns by itself
+type ContextUser interface {
+	UseContext(ctx context.Context)
+}
+
+var (
+	registryLock sync.Mutex
+	registry     = map[string]func() Player{}
+)
+
+//Register makes a bot available under a name; newPlayer is called for every game the bot plays
+//it panics if the name is taken, like registering a database driver twice
+func Register(name string, newPlayer func() Player) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, taken := registry[name]; taken {
+		panic(fmt.Sprintf("ttr: a player is already registered as %q", name))
+	}
+	registry[name] = newPlayer
+}
+
+//NewPlayer makes a new bot of the type registered under name
+func NewPlayer(name string) (Player, error) {
+	registryLock.Lock()
+	newPlayer, ok := registry[name]
+	registryLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("ttr: no player is registered as %q", name)
+	}
+	return newPlayer(), nil
+}
+
+//RegisteredPlayers lists the names bots are registered under, in order
+func RegisteredPlayers() []string {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
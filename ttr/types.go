@@ -0,0 +1,129 @@
+package ttr
+
+//Destination is a city, numbered from 0
+type Destination int
+
+//GameColor is the color of a train card or of a track
+type GameColor int
+
+const (
+	Red GameColor = iota
+	Orange
+	Yellow
+	Green
+	Blue
+	Purple
+	Black
+	White
+	Rainbow
+	Other //a grey track, which can be claimed with any one color; as a card to pick up, it means the top card of the deck
+)
+
+var colorNames = []string{"red", "orange", "yellow", "green", "blue", "purple", "black", "white", "rainbow", "grey"}
+
+func (c GameColor) String() string {
+	if c < 0 || int(c) >= len(colorNames) {
+		return "unknown"
+	}
+	return colorNames[c]
+}
+
+//RouteType says which rules a track is claimed under
+type RouteType int
+
+const (
+	NormalRoute RouteType = iota
+	TunnelRoute           //cards are turned over as it is claimed, and each one that matches the cards paid costs one more
+	FerryRoute            //some of the cards paying for it must be rainbows
+)
+
+//Track is a route between two cities
+type Track struct {
+	index       int
+	from, to    Destination
+	color       GameColor
+	length      int
+	routeType   RouteType
+	locomotives int
+}
+
+func NewTrack(index int, from, to Destination, color GameColor, length int) Track {
+	return Track{index: index, from: from, to: to, color: color, length: length}
+}
+
+//NewTunnelOrFerry makes a track of another type than NormalRoute; locomotives are for ferries only
+func NewTunnelOrFerry(index int, from, to Destination, color GameColor, length int, routeType RouteType, locomotives int) Track {
+	return Track{index: index, from: from, to: to, color: color, length: length, routeType: routeType, locomotives: locomotives}
+}
+
+//Index is the position of the track on the board, which is how tracks are named in the rest of the API
+func (t Track) Index() int {
+	return t.index
+}
+
+func (t Track) From() Destination {
+	return t.from
+}
+
+func (t Track) To() Destination {
+	return t.to
+}
+
+func (t Track) Color() GameColor {
+	return t.color
+}
+
+//Length is how many trains and train cards the track takes
+func (t Track) Length() int {
+	return t.length
+}
+
+func (t Track) Type() RouteType {
+	return t.routeType
+}
+
+//Locomotives is how many of the cards paying for a ferry must be rainbows
+func (t Track) Locomotives() int {
+	return t.locomotives
+}
+
+//Payment is the cards a track or a station is paid with: NumColored cards of Color, and NumRainbows rainbows
+//a SplitPayer or a SplitStationBuilder chooses the split; any other Player pays with cards of the color first, and rainbows for the rest
+type Payment struct {
+	Color       GameColor
+	NumColored  int
+	NumRainbows int
+}
+
+//DestinationTicket is worth its points at the end of the game if its cities are connected by the player's tracks, and costs them if they aren't
+type DestinationTicket struct {
+	from, to Destination
+	points   int
+}
+
+func NewDestinationTicket(from, to Destination, points int) DestinationTicket {
+	return DestinationTicket{from: from, to: to, points: points}
+}
+
+func (d DestinationTicket) From() Destination {
+	return d.from
+}
+
+func (d DestinationTicket) To() Destination {
+	return d.to
+}
+
+func (d DestinationTicket) Points() int {
+	return d.points
+}
+
+//Rules are the numbers the game is played with
+type Rules struct {
+	NumDestinations, NumTracks, NumColorCards, NumRainbowCards, NumStartingTrains, NumTrainsForFinalRound, NumFaceUpTrainCards, NumFaceUpRainbowsForReshuffle, NumGameColors, NumInitialTrainCardsDealt, NumInitialDestinationTicketsOffered, NumInitialDestinationTicketsPicked, NumDestinationTicketsOffered, NumDestinationTicketsPicked, NumPlayers, LongestPathScore, DoubleRouteMinPlayers int
+
+	NumStations, StationScore, NumTunnelCards, NumInitialLongDestinationTicketsOffered int //the Europe rules: stations per player and the points for each one left, the cards turned over for a tunnel, and the long tickets offered at the start
+
+	GlobetrotterScore int //the points for completing the most destination tickets, in the expanded and all tickets variants
+
+	RouteLengthScores []int //the points for claiming a track, indexed by its length
+}
@@ -0,0 +1,129 @@
+package ttr
+
+//CLOSEDTRACK is the status of a free track that the double route rules don't let the player claim
+const CLOSEDTRACK = -2
+
+//ViewState is what a PlayerView is made of; the engine fills it in
+type ViewState struct {
+	Player int
+
+	Tracks           []Track
+	TrackStatus      []int //who owns each track: -1 if it is free, CLOSEDTRACK if the double route rules keep this player off it
+	FaceUpTrainCards []int //indexed by color
+
+	DeckSize                  int
+	DiscardPileSize           int
+	DestinationTicketPileSize int
+
+	//public counts, indexed by player
+	NumTrainCards         []int
+	NumTrains             []int
+	NumDestinationTickets []int
+	StationsLeft          []int
+
+	Stations []int //who built a station in each city, or -1 if nobody has
+
+	//this player's private hand
+	TrainCards         []int //indexed by color
+	DestinationTickets []DestinationTicket
+}
+
+//PlayerView is what one player may know about the game when it is asked for a decision
+//it can't be changed once it is made: the accessors hand out copies
+type PlayerView struct {
+	state ViewState
+}
+
+//NewPlayerView makes a view out of copies of the state
+func NewPlayerView(s ViewState) PlayerView {
+	s.Tracks = append([]Track(nil), s.Tracks...)
+	s.TrackStatus = append([]int(nil), s.TrackStatus...)
+	s.FaceUpTrainCards = append([]int(nil), s.FaceUpTrainCards...)
+	s.NumTrainCards = append([]int(nil), s.NumTrainCards...)
+	s.NumTrains = append([]int(nil), s.NumTrains...)
+	s.NumDestinationTickets = append([]int(nil), s.NumDestinationTickets...)
+	s.StationsLeft = append([]int(nil), s.StationsLeft...)
+	s.Stations = append([]int(nil), s.Stations...)
+	s.TrainCards = append([]int(nil), s.TrainCards...)
+	s.DestinationTickets = append([]DestinationTicket(nil), s.DestinationTickets...)
+	return PlayerView{state: s}
+}
+
+//Player is the number of the player whose view this is
+func (v PlayerView) Player() int {
+	return v.state.Player
+}
+
+func (v PlayerView) NumPlayers() int {
+	return len(v.state.NumTrains)
+}
+
+//Tracks is the board
+func (v PlayerView) Tracks() []Track {
+	return append([]Track(nil), v.state.Tracks...)
+}
+
+//TrackStatus is who owns each track: -1 if it is free, CLOSEDTRACK if the double route rules keep this player off it
+func (v PlayerView) TrackStatus() []int {
+	return append([]int(nil), v.state.TrackStatus...)
+}
+
+//FaceUpTrainCards is how many face up cards there are of each color
+func (v PlayerView) FaceUpTrainCards() []int {
+	return append([]int(nil), v.state.FaceUpTrainCards...)
+}
+
+func (v PlayerView) DeckSize() int {
+	return v.state.DeckSize
+}
+
+func (v PlayerView) DiscardPileSize() int {
+	return v.state.DiscardPileSize
+}
+
+//NumTrainCardsLeftToDraw is how many cards can still be drawn blind: the discard pile is shuffled into the deck when it runs out
+func (v PlayerView) NumTrainCardsLeftToDraw() int {
+	return v.state.DeckSize + v.state.DiscardPileSize
+}
+
+func (v PlayerView) DestinationTicketPileSize() int {
+	return v.state.DestinationTicketPileSize
+}
+
+//NumTrainCards is how many train cards player p holds
+func (v PlayerView) NumTrainCards(p int) int {
+	return v.state.NumTrainCards[p]
+}
+
+//NumTrains is how many trains player p has left
+func (v PlayerView) NumTrains(p int) int {
+	return v.state.NumTrains[p]
+}
+
+//NumDestinationTickets is how many destination tickets player p holds
+func (v PlayerView) NumDestinationTickets(p int) int {
+	return v.state.NumDestinationTickets[p]
+}
+
+//TrainCards is this player's hand, indexed by color
+func (v PlayerView) TrainCards() []int {
+	return append([]int(nil), v.state.TrainCards...)
+}
+
+//DestinationTickets is this player's destination tickets
+func (v PlayerView) DestinationTickets() []DestinationTicket {
+	return append([]DestinationTicket(nil), v.state.DestinationTickets...)
+}
+
+//Stations is who built a station in each city: -1 if nobody has
+func (v PlayerView) Stations() []int {
+	return append([]int(nil), v.state.Stations...)
+}
+
+//StationsLeft is how many stations player p can still build
+func (v PlayerView) StationsLeft(p int) int {
+	if p < 0 || p >= len(v.state.StationsLeft) {
+		return 0
+	}
+	return v.state.StationsLeft[p]
+}
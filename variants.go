@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const GLOBETROTTERSCORE = 15 //the points for completing the most destination tickets, in the expanded and all tickets variants
+const ALLTICKETSGAMEINITIALDESTINATIONTICKETSOFFERED = 5
+const ALLTICKETSGAMEINITIALDESTINATIONTICKETSPICKED = 3
+const ALLTICKETSGAMEDESTINATIONTICKETSOFFERED = 4
+
+//Variant is a way to play the USA board modeled on the USA 1910 expansion: each one has its own ticket deck and bonuses
+//the bonuses and dealing follow the expansion's rules, but the decks are approximations built from the original deck, not the published 1910 and Big Cities decks
+type Variant int
+
+const (
+	StandardGame     Variant = iota //the board's own tickets, and the longest path bonus
+	ExpandedDeckGame                //the original deck with five more tickets, and the globetrotter bonus instead of the longest path bonus, like the 1910 game
+	HubCitiesGame                   //only tickets to the six biggest cities, and the longest path bonus, like the Big Cities game
+	AllTicketsGame                  //every ticket of every deck, both bonuses, and more tickets dealt, like the Mega Game
+)
+
+var variantNames = []string{"standard", "expanded", "hubcities", "alltickets"}
+
+func (v Variant) String() string {
+	if v < 0 || int(v) >= len(variantNames) {
+		return "unknown variant " + strconv.Itoa(int(v))
+	}
+	return variantNames[v]
+}
+
+//parseVariant reads a variant, where no variant at all is the standard game
+func parseVariant(name string) (Variant, error) {
+	if name == "" {
+		return StandardGame, nil
+	}
+	for i, variantName := range variantNames {
+		if variantName == name {
+			return Variant(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown variant %q, expected one of %v", name, variantNames)
+}
+
+//withVariant returns the constants for playing variant v on board b: its bonuses, how many tickets are dealt, and the size of its ticket deck
+func (c GameConstants) withVariant(v Variant, b *Board) (GameConstants, error) {
+	if v != StandardGame && len(b.tickets(v)) == 0 {
+		return c, fmt.Errorf("the %s board has no tickets for the %s variant", b.Name, v)
+	}
+	c.Variant = v
+	switch v {
+	case ExpandedDeckGame:
+		c.LongestPathScore = 0
+		c.GlobetrotterScore = GLOBETROTTERSCORE
+	case AllTicketsGame:
+		c.GlobetrotterScore = GLOBETROTTERSCORE
+		c.NumInitialDestinationTicketsOffered = ALLTICKETSGAMEINITIALDESTINATIONTICKETSOFFERED
+		c.NumInitialDestinationTicketsPicked = ALLTICKETSGAMEINITIALDESTINATIONTICKETSPICKED
+		c.NumDestinationTicketsOffered = ALLTICKETSGAMEDESTINATIONTICKETSOFFERED
+	}
+	return c.onBoard(b), nil
+}
+
+//allTicketsGameTickets puts every deck of a board together, leaving out the tickets between two cities that an earlier deck already has
+func allTicketsGameTickets(decks ...[]DestinationTicket) []DestinationTicket {
+	tickets := make([]DestinationTicket, 0)
+	seen := make(map[[2]Destination]bool)
+	for _, deck := range decks {
+		for _, ticket := range deck {
+			cities := [2]Destination{ticket.d1, ticket.d2}
+			if cities[0] > cities[1] {
+				cities[0], cities[1] = cities[1], cities[0]
+			}
+			if seen[cities] {
+				continue
+			}
+			seen[cities] = true
+			tickets = append(tickets, ticket)
+		}
+	}
+	return tickets
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+
+	socketio "github.com/googollee/go-socket.io"
+	"go.uber.org/zap"
+)
+
+//webPrompt is a question to a WebSocketHumanPlayer, sent to the browser as a PLAYER_PROMPT
+type webPrompt struct {
+	Player int
+	Kind   DecisionKind
+	Error  string `json:",omitempty"` //why the last answer was refused
+
+	Hand      string
+	FaceUp    []webChoice
+	MyTickets []string
+	Trains    int
+
+	Choices []webChoice //what the player can answer
+	MinKept int         `json:",omitempty"` //for tickets: how many must be kept
+}
+
+//webChoice is one answer the browser can send: Value goes in the field of the PLAYER_RESPONSE that the prompt's Kind asks for
+type webChoice struct {
+	Label    string
+	Value    int
+	Color    GameColor //for tracks and stations: the color to pay with
+	Colored  int       //for tracks and stations: how many cards of the color are paid
+	Rainbows int       //for tracks and stations: how many rainbows are paid
+	Legal    bool
+}
+
+//webResponse is an answer from the browser, a PLAYER_RESPONSE
+type webResponse struct {
+	Player   int
+	Move     int
+	Color    GameColor
+	Colored  int
+	Rainbows int
+	Track    int
+	Kept     []int
+	City     Destination
+	Pay      bool
+}
+
+//payment is the payment of a track or a station the response chose
+func (r webResponse) payment() Payment {
+	return Payment{Color: r.Color, NumColored: r.Colored, NumRainbows: r.Rainbows}
+}
+
+//webSeats connects the browser to the WebSocketHumanPlayers of a game
+//a browser plays a seat by claiming it with a CLAIM_SEAT; the seat's prompts go only to that connection, and only its answers are taken
+type webSeats struct {
+	server *socketio.Server
+
+	lock    sync.Mutex
+	seats   map[int]bool              //the seats played in the browser, and whether each was ever claimed
+	owners  map[int]socketio.Conn     //the connection playing each claimed seat, while it is connected
+	answers map[int]chan *webResponse //nil is sent when the owner of a seat drops while its prompt is open
+	prompts map[int]string            //the open prompt of every seat, sent again to a browser that claims the seat while it is open
+}
+
+func newWebSeats(server *socketio.Server) *webSeats {
+	w := &webSeats{server: server, seats: map[int]bool{}, owners: map[int]socketio.Conn{}, answers: map[int]chan *webResponse{}, prompts: map[int]string{}}
+	server.OnEvent("/", "CLAIM_SEAT", func(s socketio.Conn, msg string) {
+		w.claim(s, msg)
+	})
+	server.OnEvent("/", "PLAYER_RESPONSE", func(s socketio.Conn, msg string) {
+		w.receive(s, msg)
+	})
+	return w
+}
+
+//open makes a seat playable in the browser
+func (w *webSeats) open(seat int) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.seats[seat]; !ok {
+		w.seats[seat] = false
+		w.answers[seat] = make(chan *webResponse, 1)
+		zap.S().Infof("Player %d plays in the browser at localhost:8000/?seat=%d", seat, seat)
+	}
+}
+
+//ask sends a prompt to the browser playing its seat and waits for the answer
+//until the seat is first claimed, the prompt waits for it; once the seat's browser has left, ask returns false without waiting
+func (w *webSeats) ask(prompt webPrompt) (webResponse, bool) {
+	message, _ := json.Marshal(prompt)
+
+	w.lock.Lock()
+	owner := w.owners[prompt.Player]
+	if w.seats[prompt.Player] && owner == nil {
+		w.lock.Unlock()
+		return webResponse{}, false
+	}
+	w.prompts[prompt.Player] = string(message)
+	answers := w.answers[prompt.Player]
+	if owner != nil {
+		owner.Emit("PLAYER_PROMPT", string(message))
+	}
+	w.lock.Unlock()
+
+	response := <-answers
+	if response == nil {
+		return webResponse{}, false
+	}
+	return *response, true
+}
+
+//claim gives a seat to the browser that asked for it, unless another connected browser plays it
+func (w *webSeats) claim(s socketio.Conn, msg string) {
+	seat, err := strconv.Atoi(msg)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.seats[seat]; err != nil || !ok {
+		s.Emit("ENGINE_UPDATE", "There is no seat "+msg+" to play in the browser")
+		return
+	}
+	if owner := w.owners[seat]; owner != nil && owner.ID() != s.ID() {
+		s.Emit("ENGINE_UPDATE", "Seat "+msg+" is already played in another browser")
+		return
+	}
+	w.seats[seat] = true
+	w.owners[seat] = s
+	if prompt, open := w.prompts[seat]; open {
+		s.Emit("PLAYER_PROMPT", prompt)
+	}
+}
+
+func (w *webSeats) receive(s socketio.Conn, msg string) {
+	var response webResponse
+	if err := json.Unmarshal([]byte(msg), &response); err != nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if owner := w.owners[response.Player]; owner == nil || owner.ID() != s.ID() {
+		//	only the browser playing a seat answers for it
+		return
+	}
+	if _, open := w.prompts[response.Player]; !open {
+		//	a stray click, or a second answer to the same prompt
+		return
+	}
+	delete(w.prompts, response.Player)
+	w.answers[response.Player] <- &response
+}
+
+//drop frees the seats of a browser that disconnected, and stops the wait for their open prompts
+//the seats can be claimed again, by a reload of the page for example
+func (w *webSeats) drop(s socketio.Conn) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for seat, owner := range w.owners {
+		if owner == nil || owner.ID() != s.ID() {
+			continue
+		}
+		w.owners[seat] = nil
+		if _, open := w.prompts[seat]; open {
+			delete(w.prompts, seat)
+			w.answers[seat] <- nil
+		}
+	}
+}
+
+//WebSocketHumanPlayer lets a person play in the visualizer: every decision is a prompt in the browser, asked again until the answer is legal
+//while its seat has no browser after the first one left, it gives up tunnels and answers everything else illegally, like a bot that failed, so the illegal move policy decides what happens
+//the browser already shows the events of the game, so the player only needs to be told about its own hand
+type WebSocketHumanPlayer struct {
+	seats *webSeats
+
+	myNumber         int
+	trackList        []Track
+	destinationNames []string
+	numStations      int
+	view             PlayerView //the view of the last informStatus, which askMove, askTrackLay and askStation are answered from
+}
+
+func newWebSocketHumanPlayer(seats *webSeats) *WebSocketHumanPlayer {
+	return &WebSocketHumanPlayer{seats: seats}
+}
+
+func (w *WebSocketHumanPlayer) initialize(myNumber int, trackList []Track, adjList [][]int, constants GameConstants, rng *rand.Rand) {
+	w.myNumber = myNumber
+	w.trackList = trackList
+	w.destinationNames = constants.DestinationNames
+	w.numStations = constants.NumStations
+	w.seats.open(myNumber)
+}
+
+func (w *WebSocketHumanPlayer) informCardPickup(int, GameColor)         {}
+func (w *WebSocketHumanPlayer) informTrackLay(int, int)                 {}
+func (w *WebSocketHumanPlayer) informDestinationTicketPickup(int)       {}
+func (w *WebSocketHumanPlayer) informFinalRound(int)                    {}
+func (w *WebSocketHumanPlayer) informGameResumed([]int)                 {}
+func (w *WebSocketHumanPlayer) giveTrainCard(GameColor)                 {}
+func (w *WebSocketHumanPlayer) giveDestinationTicket(DestinationTicket) {}
+
+func (w *WebSocketHumanPlayer) informStatus(view PlayerView) {
+	w.view = view
+}
+
+//newPrompt fills in what the player holds, from a view
+func (w *WebSocketHumanPlayer) newPrompt(kind DecisionKind, view PlayerView) webPrompt {
+	prompt := webPrompt{Player: w.myNumber, Kind: kind, Hand: describeCards(view.trainCards)}
+	if w.myNumber < len(view.numTrains) {
+		//	the first tickets are offered before the first informStatus
+		prompt.Trains = view.numTrains[w.myNumber]
+	}
+	for c, howMany := range view.faceUpTrainCards {
+		if howMany > 0 {
+			prompt.FaceUp = append(prompt.FaceUp, webChoice{Label: fmt.Sprintf("%s %d", stringColors[c], howMany), Value: c, Legal: true})
+		}
+	}
+	for _, ticket := range view.destinationTickets {
+		status := "not connected yet"
+		if ticketConnected(w.trackList, view.trackStatus, w.myNumber, ticket) {
+			status = "connected"
+		}
+		prompt.MyTickets = append(prompt.MyTickets, describeTicket(w.destinationNames, ticket)+": "+status)
+	}
+	return prompt
+}
+
+func (w *WebSocketHumanPlayer) askMove() int {
+	prompt := w.newPrompt(MoveDecision, w.view)
+	labels := []string{"Draw train cards", "Claim a track", "Draw destination tickets"}
+	if w.numStations > 0 {
+		labels = append(labels, "Build a station")
+	}
+	for move, label := range labels {
+		_, broken := moveRuleBroken(w.view, move)
+		prompt.Choices = append(prompt.Choices, webChoice{Label: label, Value: move, Legal: !broken})
+	}
+	for {
+		response, ok := w.seats.ask(prompt)
+		if !ok {
+			return -1
+		}
+		rule, broken := moveRuleBroken(w.view, response.Move)
+		if !broken {
+			return response.Move
+		}
+		prompt.Error = "You can't: " + rule.String()
+	}
+}
+
+func (w *WebSocketHumanPlayer) askPickup(howManyLeft int, view PlayerView) GameColor {
+	prompt := w.newPrompt(PickupDecision, view)
+	for _, color := range legalPickups(view, howManyLeft) {
+		label := "the deck"
+		if color != Other {
+			label = "a face up " + stringColors[color] + " card"
+		}
+		prompt.Choices = append(prompt.Choices, webChoice{Label: label, Value: int(color), Legal: true})
+	}
+	for {
+		response, ok := w.seats.ask(prompt)
+		if !ok {
+			return -1
+		}
+		rule, broken := pickupRuleBroken(view, response.Color, howManyLeft)
+		if !broken {
+			return response.Color
+		}
+		prompt.Error = "You can't: " + rule.String()
+	}
+}
+
+func (w *WebSocketHumanPlayer) askTrackLay() (int, Payment) {
+	prompt := w.newPrompt(TrackDecision, w.view)
+	for _, option := range claimableTracks(w.view) {
+		for _, payment := range option.Payments {
+			label := describeTrack(w.destinationNames, w.trackList, option.Track) + ": pay with " + describePayment(payment)
+			prompt.Choices = append(prompt.Choices, webChoice{Label: label, Value: option.Track, Color: payment.Color, Colored: payment.NumColored, Rainbows: payment.NumRainbows, Legal: true})
+		}
+	}
+	for {
+		response, ok := w.seats.ask(prompt)
+		if !ok {
+			return -1, Payment{Color: Other}
+		}
+		rule, broken := trackLayRuleBroken(w.view, response.Track, response.payment())
+		if !broken {
+			return response.Track, response.payment()
+		}
+		prompt.Error = "You can't: " + rule.String()
+	}
+}
+
+func (w *WebSocketHumanPlayer) askStation() (Destination, Payment) {
+	prompt := w.newPrompt(StationDecision, w.view)
+	for _, city := range buildableStations(w.view) {
+		for _, payment := range stationPayments(w.view) {
+			label := w.destinationNames[city] + ": pay with " + describePayment(payment)
+			prompt.Choices = append(prompt.Choices, webChoice{Label: label, Value: int(city), Color: payment.Color, Colored: payment.NumColored, Rainbows: payment.NumRainbows, Legal: true})
+		}
+	}
+	for {
+		response, ok := w.seats.ask(prompt)
+		if !ok {
+			return -1, Payment{Color: Other}
+		}
+		rule, broken := stationRuleBroken(w.view, response.City, response.payment())
+		if !broken {
+			return response.City, response.payment()
+		}
+		prompt.Error = "You can't: " + rule.String()
+	}
+}
+
+func (w *WebSocketHumanPlayer) askTunnelPayment(track int, drawn []GameColor, extra Payment) bool {
+	prompt := w.newPrompt(TunnelDecision, w.view)
+	names := make([]string, len(drawn))
+	for i, c := range drawn {
+		names[i] = stringColors[c]
+	}
+	turnedOver := describeTrack(w.destinationNames, w.trackList, track) + ", with " + strings.Join(names, ", ") + " turned over"
+	prompt.Choices = []webChoice{
+		{Label: "Pay " + describePayment(extra) + " more for " + turnedOver, Value: 1, Legal: true},
+		{Label: "Give up the claim", Value: 0, Legal: true},
+	}
+	response, _ := w.seats.ask(prompt)
+	return response.Pay
+}
+
+func (w *WebSocketHumanPlayer) offerDestinationTickets(tickets []DestinationTicket, minKept int) []int {
+	prompt := w.newPrompt(TicketsDecision, w.view)
+	prompt.MinKept = minKept
+	for i, ticket := range tickets {
+		prompt.Choices = append(prompt.Choices, webChoice{Label: describeTicket(w.destinationNames, ticket), Value: i, Legal: true})
+	}
+	for {
+		response, ok := w.seats.ask(prompt)
+		if !ok {
+			return nil
+		}
+		rule, broken := ticketChoiceRuleBroken(response.Kept, len(tickets), minKept)
+		if !broken {
+			return response.Kept
+		}
+		prompt.Error = "You can't: " + rule.String()
+	}
+}